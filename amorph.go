@@ -74,3 +74,25 @@ func DeepCopy(amorphIn Amorph) (amorphOut Amorph) {
 	}
 	return //
 }
+
+// cloneAmorph copies the maps and slices of an Amorph, so that the copy
+// can be changed without changing the original. Unlike DeepCopy, values
+// are not converted to json types.
+func cloneAmorph(amorphIn Amorph) (amorphOut Amorph) {
+	switch typedIn := amorphIn.(type) {
+	case map[string]interface{}:
+		mapOut := make(map[string]interface{}, len(typedIn))
+		for k, v := range typedIn {
+			mapOut[k] = cloneAmorph(v)
+		}
+		return mapOut
+	case []interface{}:
+		sliceOut := make([]interface{}, len(typedIn))
+		for i, v := range typedIn {
+			sliceOut[i] = cloneAmorph(v)
+		}
+		return sliceOut
+	default:
+		return amorphIn
+	}
+}
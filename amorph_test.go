@@ -392,3 +392,31 @@ func Test010(t *testing.T) {
 	}
 	fmt.Println("diffs = ", amorph.PatchStringer(diffs))
 }
+
+// Walk visits each node once, with its full path
+func TestWalkLeavesOnce(t *testing.T) {
+	data, err := amorph.NewAmorphFromString(`{"employee": {"name": "John", "burgers": ["memphis", "burford"]}}`)
+	if err != nil {
+		t.FailNow()
+	}
+	visits := make(map[string]int)
+	err = amorph.Walk(data, func(iter amorph.WalkIter) error {
+		if iter.Len() == 1 {
+			return fmt.Errorf("%v visited without its path", iter.Top().Value())
+		}
+		visits[amorph.IterPath(iter).String()]++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{".employee", ".employee.burgers", ".employee.burgers[0]", ".employee.burgers[1]", ".employee.name"}
+	if len(visits) != len(want) {
+		t.Fatal(visits)
+	}
+	for _, path := range want {
+		if visits[path] != 1 {
+			t.Fatal(path, visits[path])
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package amorph
+
+// Copyright 2021 Charles J. Luciano and Scalability
+// Labs LLC. All rights reserved. Use of this source
+// code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// MarshalJSON encodes a NULL hole as a json null, so that the results
+// of set operations can be encoded. Decoding the json gives nil, not
+// NULL.
+func (nullType) MarshalJSON() ([]byte, error) {
+	return []byte("null"), nil
+}
+
+// Compact returns a copy of an Amorph without the NULL holes that
+// Difference, Intersection and the other set operations leave in
+// slices. By default the holes are removed, which moves the elements
+// after them. With OptNullsToNil they are replaced by nil instead,
+// which keeps the elements where they were and encodes the same way.
+//
+// NULL map values are always removed. A NULL Amorph stays NULL, or
+// becomes nil with OptNullsToNil.
+func Compact(a Amorph, ops ...int) Amorph {
+	options := 0
+	for _, v := range ops {
+		options = v | options
+	}
+	if OptNullsToNil&options == 0 {
+		options |= OptCompactNulls
+	}
+	return compact(a, options)
+}
+
+func compact(a Amorph, options int) Amorph {
+	switch ca := a.(type) {
+	case nullType:
+		if OptNullsToNil&options > 0 && OptCompactNulls&options == 0 {
+			return nil
+		}
+		return NULL
+	case map[string]interface{}:
+		ar := make(map[string]interface{}, len(ca))
+		for k, v := range ca {
+			if v == NULL {
+				continue
+			}
+			ar[k] = compact(v, options)
+		}
+		return ar
+	case []interface{}:
+		ar := make([]interface{}, len(ca))
+		for i, v := range ca {
+			ar[i] = compact(v, options)
+		}
+		return fillHoles(ar, options)
+	default:
+		return a
+	}
+}
+
+// fillHoles removes the NULL holes from a slice with OptCompactNulls,
+// or replaces them with nil with OptNullsToNil. The set operations call
+// it on every slice they make, so the options apply to their results.
+// The slice is changed in place.
+func fillHoles(ar []interface{}, options int) []interface{} {
+	switch {
+	case OptCompactNulls&options > 0:
+		n := 0
+		for _, v := range ar {
+			if v != NULL {
+				ar[n] = v
+				n++
+			}
+		}
+		return ar[:n]
+	case OptNullsToNil&options > 0:
+		for i, v := range ar {
+			if v == NULL {
+				ar[i] = nil
+			}
+		}
+	}
+	return ar
+}
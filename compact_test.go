@@ -0,0 +1,55 @@
+package amorph_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/clucia/amorph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNullMarshalJSON(t *testing.T) {
+	d, err := amorph.Difference([]interface{}{"a", "b", "c"}, []interface{}{"a", "x", "c"})
+	assert.Nil(t, err)
+	js, err := json.Marshal(d)
+	assert.Nil(t, err)
+	assert.Equal(t, `[null,"b",null]`, string(js))
+}
+
+func TestCompact(t *testing.T) {
+	data := map[string]interface{}{
+		"key0": []interface{}{amorph.NULL, "b", []interface{}{amorph.NULL, "c"}},
+		"key1": amorph.NULL,
+	}
+	c := amorph.Compact(data)
+	assert.True(t, amorph.DeepEqual(c, map[string]interface{}{
+		"key0": []interface{}{"b", []interface{}{"c"}},
+	}))
+	c = amorph.Compact(data, amorph.OptNullsToNil)
+	assert.True(t, amorph.DeepEqual(c, map[string]interface{}{
+		"key0": []interface{}{nil, "b", []interface{}{nil, "c"}},
+	}))
+	// the input is unchanged
+	assert.Equal(t, amorph.NULL, data["key0"].([]interface{})[0])
+}
+
+func TestSetOperationHoles(t *testing.T) {
+	data0 := []interface{}{"a", "b", "c"}
+	data1 := []interface{}{"a", "x", "c"}
+
+	d, err := amorph.Difference(data0, data1, amorph.OptCompactNulls)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(d, []interface{}{"b"}))
+
+	i, err := amorph.Intersection(data0, data1, amorph.OptNullsToNil)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(i, []interface{}{"a", nil, "c"}))
+
+	i, err = amorph.TopoIntersection(data0, []interface{}{amorph.NULL, "y"}, amorph.OptCompactNulls, amorph.OptResolveAmorph0)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(i, []interface{}{"b"}))
+
+	sd, err := amorph.SymmetricDifference(data0, []interface{}{"a", "b"}, amorph.OptCompactNulls)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(sd, []interface{}{"c"}))
+}
@@ -11,7 +11,30 @@ package amorph
 //
 // The differences from amorph0 to amorph1 are considered to be
 // the Forward direction.
-func Diff(amorph0, amorph1 Amorph) (patch Patch) {
+//
+// The OptDiffHash option records the Hash of amorph0 and amorph1 in
+// the patch. PatchFwd and PatchRev will then refuse to apply the patch
+// to anything else. With OptDiffHash, a patch is produced even when the
+// two Amorphs are equal, so that the precondition is never lost.
+//
+// By default slices are compared position by position. The OptDiffSplice
+// option compares them as sequences instead, producing a splice patch
+// that records inserted, deleted and changed elements. Splice patches
+// are larger to compute but keep their meaning when an element is
+// inserted or removed in the middle of a slice, which Rebase needs.
+func Diff(amorph0, amorph1 Amorph, ops ...int) (patch Patch) {
+	options := 0
+	for _, v := range ops {
+		options = v | options
+	}
+	patch = diff(amorph0, amorph1, options)
+	if OptDiffHash&options > 0 {
+		patch = bindPatch(patch, amorph0, amorph1)
+	}
+	return patch
+}
+
+func diff(amorph0, amorph1 Amorph, options int) (patch Patch) {
 	switch cvtd0 := amorph0.(type) {
 	case nil:
 		return map[string]interface{}{
@@ -25,9 +48,9 @@ func Diff(amorph0, amorph1 Amorph) (patch Patch) {
 	case string:
 		return stringDiff(cvtd0, amorph1)
 	case []interface{}:
-		return sliceDiff(cvtd0, amorph1)
+		return sliceDiff(cvtd0, amorph1, options)
 	case map[string]interface{}:
-		return mapDiff(cvtd0, amorph1)
+		return mapDiff(cvtd0, amorph1, options)
 	default:
 		return map[string]interface{}{
 			"typ":       "raw",
@@ -91,8 +114,7 @@ func stringDiff(str0 string, amorph1 Amorph) (patch Patch) {
 	}
 }
 
-func mapDiff(map0 map[string]interface{}, amorph1 Amorph) (patch Patch) {
-	prune := true
+func mapDiff(map0 map[string]interface{}, amorph1 Amorph, options int) (patch Patch) {
 	if map0 == nil {
 		panic("Shouldn't happen")
 	}
@@ -111,6 +133,15 @@ func mapDiff(map0 map[string]interface{}, amorph1 Amorph) (patch Patch) {
 			"valRev": map0,
 		}
 	}
+	return mapDiffElems(map0, map1, func(k string) Patch {
+		return diff(map0[k], map1[k], options)
+	})
+}
+
+// mapDiffElems builds the patch for two maps. elemDiff is called to diff
+// the values of keys present in both maps.
+func mapDiffElems(map0, map1 map[string]interface{}, elemDiff func(k string) Patch) (patch Patch) {
+	prune := true
 	keys := make(map[string]struct{})
 	for k := range map0 {
 		keys[k] = struct{}{}
@@ -121,6 +152,8 @@ func mapDiff(map0 map[string]interface{}, amorph1 Amorph) (patch Patch) {
 	mapPatch := map[string]interface{}{
 		"typ":    "map",
 		"valFwd": make(map[string]interface{}),
+		"lenFwd": len(map1),
+		"lenRev": len(map0),
 	}
 	for k := range keys {
 		var elemPatch Patch
@@ -131,7 +164,7 @@ func mapDiff(map0 map[string]interface{}, amorph1 Amorph) (patch Patch) {
 			panic("Shouldn't happen")
 		}
 		if ok0 && ok1 {
-			elemPatch = Diff(map0[k], map1[k])
+			elemPatch = elemDiff(k)
 			if elemPatch == nil {
 				continue
 			}
@@ -162,8 +195,7 @@ func max(a, b int) int {
 	return b
 }
 
-func sliceDiff(slice0 []interface{}, amorph1 Amorph) (patch Patch) {
-	prune := true
+func sliceDiff(slice0 []interface{}, amorph1 Amorph, options int) (patch Patch) {
 	if slice0 == nil {
 		panic("Shouldn't happen")
 	}
@@ -178,12 +210,25 @@ func sliceDiff(slice0 []interface{}, amorph1 Amorph) (patch Patch) {
 	slice1, ok := amorph1.([]interface{})
 	if !ok {
 		return map[string]interface{}{
-			"typ":    "slice",
+			"typ":    "raw",
 			"valFwd": amorph1,
 			"valRev": slice0,
 			"lenRev": len(slice0),
 		}
 	}
+	if OptDiffSplice&options > 0 {
+		// hash every subtree once, rather than again at each nested slice
+		return diffHashTree(NewHashTree(slice0), NewHashTree(slice1), options)
+	}
+	return sliceDiffElems(slice0, slice1, func(i int) Patch {
+		return diff(slice0[i], slice1[i], options)
+	})
+}
+
+// sliceDiffElems builds the patch for two slices. elemDiff is called to
+// diff the elements at indexes present in both slices.
+func sliceDiffElems(slice0, slice1 []interface{}, elemDiff func(i int) Patch) (patch Patch) {
+	prune := true
 	l0 := len(slice0)
 	l1 := len(slice1)
 	lmax := max(l0, l1)
@@ -197,7 +242,7 @@ func sliceDiff(slice0 []interface{}, amorph1 Amorph) (patch Patch) {
 		var elementPatch Patch
 		switch {
 		case i < l0 && i < l1:
-			elementPatch = Diff(slice0[i], slice1[i])
+			elementPatch = elemDiff(i)
 		case i < l0:
 			elementPatch = map[string]interface{}{
 				"typ":       "raw",
@@ -4,36 +4,45 @@ package amorph
 // that isn't subtracted by the subtrahend.
 //
 // By default, the subtrahend must match all the way to the leaf values for a subtraction
-// to occur.
+// to occur. DifferenceFunc matches the leaf values with a LeafEqual instead.
 //
 // By default, elements in the subtrahend that have no topological match in the minuend
-// are ignored. The OptMustSubtract option causes Difference to treat this as an error.
-//
-// The OptIgnoreLeafValues option says that the subtraction may occur if there's ANY
-// value in topologically the same place in the two Amorphs.
+// are ignored. The OptDifferenceMustSubtract option causes Difference to treat this as an error.
 //
+// TopoDifference subtracts if there's ANY value in topologically the same place in the
+// two Amorphs.
 func Difference(min, a1 Amorph, ops ...int) (Amorph, error) {
 	options := 0
 	for _, v := range ops {
 		options = options | v
 	}
-	return difference(min, a1, options)
+	return difference(min, a1, setOp{options: options})
 }
 
-func sliceDifference(min, sub Amorph, options int) (Amorph, error) {
+func sliceDifference(min, sub Amorph, op setOp) (Amorph, error) {
 	m := min.([]interface{})
 	s := sub.([]interface{})
+	if op.key != "" {
+		return sliceKeyedDifference(m, s, op)
+	}
+	if sliceSetOption(op.options) {
+		ar, err := sliceSetDifference(m, s, op.options)
+		if err != nil {
+			return nil, pathError(op.path, err)
+		}
+		return ar, nil
+	}
 
 	ar := NewNullSlice(len(m)).([]interface{})
 
-	if len(s) > len(m) && (OptDifferenceMustSubtract&options) > 0 {
-		return nil, ErrMustSubtract
+	if len(s) > len(m) && (OptDifferenceMustSubtract&op.options) > 0 {
+		return nil, pathError(appendPath(op.path, len(m)), ErrMustSubtract)
 	}
 
 	var err error
 	for i, v := range m {
 		if i < len(s) {
-			ar[i], err = difference(v, s[i], options)
+			ar[i], err = difference(v, s[i], op.descend(i))
 			if err != nil {
 				return nil, err
 			}
@@ -42,10 +51,10 @@ func sliceDifference(min, sub Amorph, options int) (Amorph, error) {
 		}
 	}
 
-	return ar, nil
+	return fillHoles(ar, op.options), nil
 }
 
-func mapDifference(min, sub Amorph, options int) (Amorph, error) {
+func mapDifference(min, sub Amorph, op setOp) (Amorph, error) {
 	m := min.(map[string]interface{})
 	s := sub.(map[string]interface{})
 	keys := make(map[string]struct{})
@@ -64,12 +73,12 @@ func mapDifference(min, sub Amorph, options int) (Amorph, error) {
 		case ok0 && !ok1:
 			ar[k] = m[k]
 		case !ok0 && ok1:
-			if OptDifferenceMustSubtract&options > 0 {
-				return nil, ErrMustSubtract
+			if OptDifferenceMustSubtract&op.options > 0 {
+				return nil, pathError(appendPath(op.path, k), ErrMustSubtract)
 			}
 		case ok0 && ok1:
 			var res Amorph
-			res, err = difference(v0, v1, options)
+			res, err = difference(v0, v1, op.descend(k))
 			if err != nil {
 				return nil, err
 			}
@@ -78,68 +87,177 @@ func mapDifference(min, sub Amorph, options int) (Amorph, error) {
 			}
 			ar[k] = res
 		default:
-			panic("")
-
+			return nil, pathError(appendPath(op.path, k), ErrUnsupportedType)
 		}
 	}
 	return ar, nil
 }
 
-func difference(min, sub Amorph, options int) (Amorph, error) {
+func difference(min, sub Amorph, op setOp) (Amorph, error) {
 	switch min.(type) {
 	case nullType:
 		return NULL, nil
-	case nil:
+	case nil, string, float64:
+	case map[string]interface{}:
 		switch sub.(type) {
-		case nil:
-			return NULL, nil
-		default:
-			if OptDifferenceMustSubtract&options > 0 {
-				return NULL, ErrMustSubtract
-			}
-			return nil, nil
+		case map[string]interface{}:
+			return mapDifference(min, sub, op)
 		}
-	case string:
+	case []interface{}:
 		switch sub.(type) {
-		case string:
-			if min == sub {
-				return NULL, nil
-			}
-		}
-		if OptDifferenceMustSubtract&options > 0 {
-			return NULL, ErrMustSubtract
+		case []interface{}:
+			return sliceDifference(min, sub, op)
 		}
+	default:
+		return nil, pathError(op.path, ErrUnsupportedType)
+	}
+	if _, hole := sub.(nullType); !hole && op.leafEqual(min, sub) {
+		return NULL, nil
+	}
+	if OptDifferenceMustSubtract&op.options > 0 {
+		return NULL, pathError(op.path, ErrMustSubtract)
+	}
+	return min, nil
+}
+
+// DifferenceAll produces an Amorph that contains everything from the
+// minuend that isn't subtracted by any of the subtrahends, in a single
+// pass. Without options it is the same as nesting Difference calls.
+//
+// With OptDifferenceMustSubtract, every subtrahend must subtract from
+// the minuend itself, so the result does not depend on the order of the
+// subtrahends. (A nested Difference call would instead complain when a
+// later subtrahend subtracts something an earlier one already removed.)
+//
+// Unlike Difference, the options come first, as a single int (0 for
+// none), since the subtrahends take the variadic parameter.
+func DifferenceAll(ops int, min Amorph, subs ...Amorph) (Amorph, error) {
+	ar, err := differenceAll(min, subs, ops)
+	return ar, pathError(Path{}, err)
+}
+
+func differenceAll(min Amorph, subs []Amorph, options int) (Amorph, error) {
+	if len(subs) == 0 {
 		return min, nil
-	case float64:
-		switch sub.(type) {
-		case float64:
-			if min == sub {
-				return NULL, nil
+	}
+	switch min.(type) {
+	case nullType:
+		return NULL, nil
+	case nil, string, float64:
+		matched := false
+		for _, s := range subs {
+			switch s.(type) {
+			case nil, string, float64:
+				if s == min {
+					matched = true
+					continue
+				}
+			}
+			if OptDifferenceMustSubtract&options > 0 {
+				return NULL, ErrMustSubtract
 			}
 		}
-		if OptDifferenceMustSubtract&options > 0 {
-			return NULL, ErrMustSubtract
+		if matched {
+			return NULL, nil
 		}
 		return min, nil
 	case map[string]interface{}:
-		switch sub.(type) {
-		case map[string]interface{}:
-			return mapDifference(min, sub, options)
+		return mapDifferenceAll(min, subs, options)
+	case []interface{}:
+		return sliceDifferenceAll(min, subs, options)
+	default:
+		return nil, ErrUnsupportedType
+	}
+}
+
+func mapDifferenceAll(min Amorph, subs []Amorph, options int) (Amorph, error) {
+	m := min.(map[string]interface{})
+	keySubs := make(map[string][]Amorph)
+	for _, sub := range subs {
+		s, ok := sub.(map[string]interface{})
+		if !ok {
+			if OptDifferenceMustSubtract&options > 0 {
+				return nil, ErrMustSubtract
+			}
+			continue
 		}
-		if OptDifferenceMustSubtract&options > 0 {
-			return nil, ErrMustSubtract
+		for k, v := range s {
+			if _, ok := m[k]; !ok && OptDifferenceMustSubtract&options > 0 {
+				return nil, prependPathError(k, ErrMustSubtract)
+			}
+			keySubs[k] = append(keySubs[k], v)
 		}
-		return min, nil
-	case []interface{}:
-		switch sub.(type) {
-		case []interface{}:
-			return sliceDifference(min, sub, options)
+	}
+	ar := make(map[string]interface{})
+	for k, v := range m {
+		res, err := differenceAll(v, keySubs[k], options)
+		if err != nil {
+			return nil, prependPathError(k, err)
+		}
+		if res == NULL {
+			continue
+		}
+		ar[k] = res
+	}
+	return ar, nil
+}
+
+func sliceDifferenceAll(min Amorph, subs []Amorph, options int) (Amorph, error) {
+	m := min.([]interface{})
+	if sliceSetOption(options) {
+		return sliceSetDifferenceAll(m, subs, options)
+	}
+	idxSubs := make([][]Amorph, len(m))
+	for _, sub := range subs {
+		s, ok := sub.([]interface{})
+		if !ok || len(s) > len(m) {
+			if OptDifferenceMustSubtract&options > 0 {
+				return nil, ErrMustSubtract
+			}
+			if !ok {
+				continue
+			}
+		}
+		for i, v := range s {
+			if i < len(m) {
+				idxSubs[i] = append(idxSubs[i], v)
+			}
+		}
+	}
+	var err error
+	ar := NewNullSlice(len(m)).([]interface{})
+	for i, v := range m {
+		ar[i], err = differenceAll(v, idxSubs[i], options)
+		if err != nil {
+			return nil, prependPathError(i, err)
+		}
+	}
+	return fillHoles(ar, options), nil
+}
+
+// sliceSetDifferenceAll subtracts each subtrahend in turn. With
+// OptDifferenceMustSubtract each one is checked against the minuend
+// itself, as for maps and slices compared by index.
+func sliceSetDifferenceAll(m []interface{}, subs []Amorph, options int) (Amorph, error) {
+	ar := m
+	for _, sub := range subs {
+		s, ok := sub.([]interface{})
+		if !ok {
+			if OptDifferenceMustSubtract&options > 0 {
+				return nil, ErrMustSubtract
+			}
+			continue
 		}
 		if OptDifferenceMustSubtract&options > 0 {
-			return nil, ErrMustSubtract
+			if _, err := sliceSetDifference(m, s, options); err != nil {
+				return nil, err
+			}
+		}
+		var err error
+		ar, err = sliceSetDifference(ar, s, options&^OptDifferenceMustSubtract)
+		if err != nil {
+			return nil, err
 		}
-		return min, nil
-	default:
-		panic("")
 	}
+	return ar, nil
 }
@@ -1,6 +1,7 @@
 package amorph_test
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
@@ -45,7 +46,11 @@ func TestDifferencePrimitiveTypeString(t *testing.T) {
 
 	diff, err = amorph.Difference(data0, data1, amorph.OptDifferenceMustSubtract)
 	assert.Equal(t, diff, amorph.NULL)
-	assert.Equal(t, err, amorph.ErrMustSubtract)
+	assert.True(t, errors.Is(err, amorph.ErrMustSubtract))
+	// a *PathError that wraps ErrMustSubtract, rather than ErrMustSubtract itself
+	var pe *amorph.PathError
+	assert.True(t, errors.As(err, &pe))
+	assert.NotEqual(t, amorph.ErrMustSubtract, err)
 	fmt.Println("diff = ", diff, ", err = ", err)
 }
 
@@ -69,7 +74,7 @@ func TestDifferencePrimitiveTypeFloat64(t *testing.T) {
 
 	diff, err = amorph.Difference(data0, data1, amorph.OptDifferenceMustSubtract)
 	assert.Equal(t, diff, amorph.NULL)
-	assert.Equal(t, err, amorph.ErrMustSubtract)
+	assert.True(t, errors.Is(err, amorph.ErrMustSubtract))
 	fmt.Println("diff = ", diff, ", err = ", err)
 
 	fmt.Println("diff = ", diff, ", err = ", err)
@@ -111,7 +116,7 @@ func TestDifferenceSlice(t *testing.T) {
 
 	diff, err = amorph.Difference(data0, data1, amorph.OptDifferenceMustSubtract)
 	assert.Nil(t, diff)
-	assert.Equal(t, err, amorph.ErrMustSubtract)
+	assert.True(t, errors.Is(err, amorph.ErrMustSubtract))
 	fmt.Println("diff = ", diff, ", err = ", err)
 
 	result2 := []interface{}{
@@ -153,3 +158,44 @@ func TestDifference001(t *testing.T) {
 	fmt.Println("diff = ", diff, ", err = ", err)
 
 }
+
+func TestDifferenceAll(t *testing.T) {
+	min := map[string]interface{}{
+		"key0": "value0",
+		"key1": "value1",
+		"key2": "value2",
+	}
+	sub0 := map[string]interface{}{
+		"key0": "value0",
+	}
+	sub1 := map[string]interface{}{
+		"key0": "value0",
+		"key1": "value1",
+	}
+	res := map[string]interface{}{
+		"key2": "value2",
+	}
+	d, err := amorph.DifferenceAll(0, min, sub0, sub1)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(d, res))
+
+	// both subtrahends subtract key0 from the minuend
+	d, err = amorph.DifferenceAll(amorph.OptDifferenceMustSubtract, min, sub0, sub1)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(d, res))
+
+	_, err = amorph.DifferenceAll(amorph.OptDifferenceMustSubtract, min, sub0, map[string]interface{}{"key3": "value3"})
+	assert.True(t, errors.Is(err, amorph.ErrMustSubtract))
+}
+
+func TestDifferenceUnsupportedType(t *testing.T) {
+	data := map[string]interface{}{"a": true}
+	_, err := amorph.Difference(data, data)
+	assert.True(t, errors.Is(err, amorph.ErrUnsupportedType))
+	var pe *amorph.PathError
+	assert.True(t, errors.As(err, &pe))
+	assert.Equal(t, amorph.Path{"a"}, pe.Path)
+
+	_, err = amorph.DifferenceFunc(data, data, amorph.AnyLeaf)
+	assert.True(t, errors.Is(err, amorph.ErrUnsupportedType))
+}
@@ -6,3 +6,22 @@ var ErrMustSubtract = fmt.Errorf("can't subtract")
 var ErrUnionNoSlicify = fmt.Errorf("Cannot perform union without slicify")
 var ErrIntersectionNoSlicify = fmt.Errorf("Cannot perform intersection without slicify")
 var ErrUnsupportedType = fmt.Errorf("unsupported type")
+var ErrPatchBaseMismatch = fmt.Errorf("patch applied forward to a different base")
+var ErrPatchTargetMismatch = fmt.Errorf("patch applied in reverse to a different target")
+var ErrRebaseConflict = fmt.Errorf("cannot rebase a change to something the other patch removed or replaced")
+var ErrConflict = fmt.Errorf("conflicting values")
+var ErrStrategicDirective = fmt.Errorf("unknown strategic merge directive")
+var ErrQuorumSize = fmt.Errorf("quorum must be between 1 and the number of documents")
+var ErrPointerSyntax = fmt.Errorf("invalid JSON pointer")
+var ErrPointerNotFound = fmt.Errorf("JSON pointer key not found")
+var ErrPointerIndex = fmt.Errorf("JSON pointer index not in the array")
+var ErrPointerLeaf = fmt.Errorf("JSON pointer goes through a leaf value")
+var ErrQuerySyntax = fmt.Errorf("invalid JSONPath query")
+var ErrQueryFunction = fmt.Errorf("unknown JSONPath function or wrong argument types")
+var ErrPathSyntax = fmt.Errorf("invalid path")
+var ErrPatternSyntax = fmt.Errorf("invalid path pattern")
+var ErrFlattenSyntax = fmt.Errorf("invalid flattened key")
+var ErrFlattenConflict = fmt.Errorf("flattened keys disagree about a node")
+var ErrFlattenSeparator = fmt.Errorf("invalid flatten separator")
+var ErrGronSyntax = fmt.Errorf("invalid gron assignment")
+var ErrGronConflict = fmt.Errorf("gron assignments disagree about a node")
@@ -0,0 +1,83 @@
+package amorph
+
+// Copyright 2021 Charles J. Luciano and Scalability
+// Labs LLC. All rights reserved. Use of this source
+// code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Factor splits a collection of similar Amorphs into the base they all
+// share, which is their IntersectionAll, and an override for each
+// document, so that Union(base, overrides[i]) is DeepEqual to docs[i].
+//
+// An override has only what its document doesn't share with the base:
+// the maps in it leave out the keys the base already has the same
+// value for, and the slices have NULL holes for the elements the base
+// already has, with the trailing holes trimmed off. The override of a
+// document equal to the base is NULL.
+//
+// The overrides are meant to be combined with Union as they are; the
+// holes don't survive a round trip through json.
+func Factor(docs ...Amorph) (base Amorph, overrides []Amorph, err error) {
+	base, err = IntersectionAll(0, docs...)
+	if err != nil {
+		return nil, nil, err
+	}
+	overrides = make([]Amorph, len(docs))
+	for i, doc := range docs {
+		overrides[i] = factorOverride(doc, base)
+	}
+	return base, overrides, nil
+}
+
+// factorOverride returns what doc has that base doesn't, or NULL if
+// base covers all of doc.
+func factorOverride(doc, base Amorph) Amorph {
+	switch d := doc.(type) {
+	case map[string]interface{}:
+		b, ok := base.(map[string]interface{})
+		if !ok {
+			return doc
+		}
+		ar := make(map[string]interface{})
+		for k, v := range d {
+			bv, ok := b[k]
+			if !ok {
+				ar[k] = v
+				continue
+			}
+			if o := factorOverride(v, bv); o != NULL {
+				ar[k] = o
+			}
+		}
+		if len(ar) == 0 {
+			return NULL
+		}
+		return ar
+	case []interface{}:
+		b, ok := base.([]interface{})
+		if !ok {
+			return doc
+		}
+		ar := NewNullSlice(len(d)).([]interface{})
+		last := -1
+		for i, v := range d {
+			if i < len(b) {
+				ar[i] = factorOverride(v, b[i])
+			} else {
+				ar[i] = v
+			}
+			if ar[i] != NULL {
+				last = i
+			}
+		}
+		if last < 0 {
+			return NULL
+		}
+		return ar[:last+1]
+	default:
+		if _, hole := base.(nullType); !hole && base == doc {
+			return NULL
+		}
+		return doc
+	}
+}
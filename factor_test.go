@@ -0,0 +1,55 @@
+package amorph_test
+
+import (
+	"testing"
+
+	"github.com/clucia/amorph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFactor(t *testing.T) {
+	docs := []amorph.Amorph{
+		map[string]interface{}{"port": 80.0, "name": "a", "tags": []interface{}{"x", "y", "z"}, "empty": map[string]interface{}{}},
+		map[string]interface{}{"port": 80.0, "name": "b", "tags": []interface{}{"x", "q"}, "empty": map[string]interface{}{}},
+		map[string]interface{}{"port": 80.0, "name": "c", "tags": []interface{}{"x", "y"}, "empty": map[string]interface{}{}},
+	}
+	base, overrides, err := amorph.Factor(docs...)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(base, map[string]interface{}{
+		"port":  80.0,
+		"tags":  []interface{}{"x", amorph.NULL},
+		"empty": map[string]interface{}{},
+	}))
+	assert.True(t, amorph.DeepEqual(overrides[0], map[string]interface{}{
+		"name": "a",
+		"tags": []interface{}{amorph.NULL, "y", "z"},
+	}))
+	for i, doc := range docs {
+		u, err := amorph.Union(base, overrides[i])
+		assert.Nil(t, err)
+		assert.True(t, amorph.DeepEqual(u, doc))
+	}
+
+	// a document equal to the base has no override
+	base, overrides, err = amorph.Factor(docs[0], docs[0])
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(base, docs[0]))
+	assert.Equal(t, amorph.NULL, overrides[1])
+}
+
+func TestFactorJSON(t *testing.T) {
+	data, err := amorph.NewAmorphFromFile("test.json")
+	assert.Nil(t, err)
+	docs := make([]amorph.Amorph, 0)
+	for _, v := range data.([]interface{}) {
+		docs = append(docs, v)
+	}
+	base, overrides, err := amorph.Factor(docs...)
+	assert.Nil(t, err)
+	assert.Len(t, overrides, len(docs))
+	for i, doc := range docs {
+		u, err := amorph.Union(base, overrides[i])
+		assert.Nil(t, err)
+		assert.True(t, amorph.DeepEqual(u, doc), "doc %d", i)
+	}
+}
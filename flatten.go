@@ -0,0 +1,336 @@
+package amorph
+
+// Copyright 2021 Charles J. Luciano and Scalability
+// Labs LLC. All rights reserved. Use of this source
+// code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FlattenOptions sets how Flatten and Unflatten write a Path as a single
+// key. The zero value writes "config.webaddresses.0".
+type FlattenOptions struct {
+	// Separator goes between map keys; "" means ".". It can't begin
+	// with a backslash, or with "[" when Brackets is set.
+	Separator string
+	// Brackets writes slice indexes as "[0]" instead of as a key.
+	Brackets bool
+}
+
+// A FlattenError reports the flattened key that Flatten or Unflatten
+// couldn't handle.
+type FlattenError struct {
+	Key string
+	Err error
+}
+
+func (fe *FlattenError) Error() string {
+	return strconv.Quote(fe.Key) + ": " + fe.Err.Error()
+}
+
+func (fe *FlattenError) Unwrap() error {
+	return fe.Err
+}
+
+// Flatten returns a map from the Path of each leaf in a, written as a
+// single key, to the leaf. Empty maps and slices are kept as values, so
+// that Unflatten with the same options gives back a.
+//
+// A backslash escapes the first byte of the separator, a "[" (with
+// Brackets) and a backslash in a map key. Without Brackets, a map key
+// made only of digits has its first digit escaped, so that it can't be
+// read as a slice index.
+//
+// A leaf or an empty map or slice at the top of a has the key "". A map
+// key "" at the top with such a value (or, with Brackets, a slice) would
+// have the same keys as a itself, so Flatten returns a *FlattenError
+// wrapping ErrFlattenConflict for it.
+func Flatten(a Amorph, opts FlattenOptions) (map[string]interface{}, error) {
+	sep, err := opts.separator()
+	if err != nil {
+		return nil, err
+	}
+	flat := map[string]interface{}{}
+	err = flatten(a, "", true, sep, opts.Brackets, flat)
+	return flat, err
+}
+
+func flatten(a Amorph, key string, top bool, sep string, brackets bool, flat map[string]interface{}) error {
+	join := func(k string) string {
+		if top {
+			return k
+		}
+		return key + sep + k
+	}
+	switch ca := a.(type) {
+	case map[string]interface{}:
+		if len(ca) == 0 {
+			break
+		}
+		for k, v := range ca {
+			nkey := join(flattenKey(k, sep, brackets))
+			if _, isSlice := v.([]interface{}); top && nkey == "" && (isFlatLeaf(v) || (brackets && isSlice)) {
+				return &FlattenError{Key: nkey, Err: ErrFlattenConflict}
+			}
+			if err := flatten(v, nkey, false, sep, brackets, flat); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []interface{}:
+		if len(ca) == 0 {
+			break
+		}
+		for i, v := range ca {
+			nkey := join(strconv.Itoa(i))
+			if brackets {
+				nkey = key + "[" + strconv.Itoa(i) + "]"
+			}
+			if err := flatten(v, nkey, false, sep, brackets, flat); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	flat[key] = a
+	return nil
+}
+
+// isFlatLeaf reports whether Flatten keeps v as a value.
+func isFlatLeaf(v Amorph) bool {
+	switch cv := v.(type) {
+	case map[string]interface{}:
+		return len(cv) == 0
+	case []interface{}:
+		return len(cv) == 0
+	}
+	return true
+}
+
+// flattenKey escapes a map key.
+func flattenKey(k, sep string, brackets bool) string {
+	var sb strings.Builder
+	for i := 0; i < len(k); i++ {
+		c := k[i]
+		if c == '\\' || c == sep[0] || (c == '[' && brackets) || (i == 0 && !brackets && isDigits(k)) {
+			sb.WriteByte('\\')
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// Unflatten builds the Amorph that Flatten, with the same options, made
+// flat from. Slice indexes that are missing are NULL holes, and an
+// empty flat map gives an empty map.
+//
+// So that a short flat map can't make a huge slice, Unflatten fills in
+// at most 65536 holes plus 64 for each byte of the keys.
+//
+// Unflatten returns a *FlattenError wrapping ErrFlattenSyntax for a key
+// it can't read, or whose index is above 1<<53-1 or needs more holes
+// than that, and ErrFlattenConflict for keys that make a node both a
+// leaf and a map or slice, or both a map and a slice.
+func Unflatten(flat map[string]interface{}, opts FlattenOptions) (Amorph, error) {
+	sep, err := opts.separator()
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(flat))
+	for k := range flat {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	root := &flatNode{}
+	holes := flattenHoles
+	for _, k := range keys {
+		holes += flattenHolesPerByte * len(k)
+		path, err := parseFlatKey(k, sep, opts.Brackets)
+		if err != nil {
+			return nil, &FlattenError{Key: k, Err: err}
+		}
+		node := root
+		for _, key := range path {
+			node = node.child(key)
+		}
+		node.leaf, node.value = true, flat[k]
+	}
+	if len(flat) == 0 {
+		return map[string]interface{}{}, nil
+	}
+	return root.build(Path{}, sep, opts.Brackets, &holes)
+}
+
+// The NULL holes Unflatten may fill in.
+const (
+	flattenHoles        = 1 << 16
+	flattenHolesPerByte = 64
+)
+
+// flatNode collects the keys below a node while Unflatten reads them.
+type flatNode struct {
+	leaf    bool
+	value   interface{}
+	keys    map[string]*flatNode
+	indexes map[int]*flatNode
+}
+
+func (fn *flatNode) child(key interface{}) *flatNode {
+	var child *flatNode
+	switch ckey := key.(type) {
+	case int:
+		if fn.indexes == nil {
+			fn.indexes = map[int]*flatNode{}
+		}
+		if child = fn.indexes[ckey]; child == nil {
+			child = &flatNode{}
+			fn.indexes[ckey] = child
+		}
+	case string:
+		if fn.keys == nil {
+			fn.keys = map[string]*flatNode{}
+		}
+		if child = fn.keys[ckey]; child == nil {
+			child = &flatNode{}
+			fn.keys[ckey] = child
+		}
+	}
+	return child
+}
+
+// build makes the Amorph below fn. holes is how many more NULL holes it
+// may fill in.
+func (fn *flatNode) build(path Path, sep string, brackets bool, holes *int) (Amorph, error) {
+	if (fn.leaf && (fn.keys != nil || fn.indexes != nil)) || (fn.keys != nil && fn.indexes != nil) {
+		return nil, &FlattenError{Key: formatFlatKey(path, sep, brackets), Err: ErrFlattenConflict}
+	}
+	switch {
+	case fn.leaf:
+		return fn.value, nil
+	case fn.keys != nil:
+		ar := make(map[string]interface{}, len(fn.keys))
+		for k, child := range fn.keys {
+			v, err := child.build(path.Append(k), sep, brackets, holes)
+			if err != nil {
+				return nil, err
+			}
+			ar[k] = v
+		}
+		return ar, nil
+	}
+	l := 0
+	for i := range fn.indexes {
+		if i >= l {
+			l = i + 1
+		}
+	}
+	if l-len(fn.indexes) > *holes {
+		return nil, &FlattenError{Key: formatFlatKey(path.Append(l-1), sep, brackets), Err: ErrFlattenSyntax}
+	}
+	*holes -= l - len(fn.indexes)
+	ar := NewNullSlice(l).([]interface{})
+	for i, child := range fn.indexes {
+		v, err := child.build(path.Append(i), sep, brackets, holes)
+		if err != nil {
+			return nil, err
+		}
+		ar[i] = v
+	}
+	return ar, nil
+}
+
+// formatFlatKey writes path as Flatten does.
+func formatFlatKey(path Path, sep string, brackets bool) string {
+	var sb strings.Builder
+	for n, key := range path {
+		if i, ok := key.(int); ok && brackets {
+			sb.WriteString("[" + strconv.Itoa(i) + "]")
+			continue
+		}
+		if n > 0 {
+			sb.WriteString(sep)
+		}
+		switch ckey := key.(type) {
+		case int:
+			sb.WriteString(strconv.Itoa(ckey))
+		case string:
+			sb.WriteString(flattenKey(ckey, sep, brackets))
+		}
+	}
+	return sb.String()
+}
+
+// parseFlatKey reads a key written by Flatten back into a Path.
+func parseFlatKey(k, sep string, brackets bool) (Path, error) {
+	path := Path{}
+	if k == "" {
+		return path, nil
+	}
+	for i := 0; ; i += len(sep) {
+		if !brackets || i > 0 || k[0] != '[' {
+			var key strings.Builder
+			escaped := false
+			for ; i < len(k) && !strings.HasPrefix(k[i:], sep) && !(brackets && k[i] == '['); i++ {
+				if k[i] == '\\' {
+					if i+1 == len(k) {
+						return nil, ErrFlattenSyntax
+					}
+					escaped = escaped || key.Len() == 0
+					i++
+				}
+				key.WriteByte(k[i])
+			}
+			switch n, err := strconv.Atoi(key.String()); {
+			case brackets || escaped || !isDigits(key.String()):
+				path = append(path, key.String())
+			case err != nil || n > maxIndex || strconv.Itoa(n) != key.String():
+				return nil, ErrFlattenSyntax // "01" is neither a key nor an index
+			default:
+				path = append(path, n)
+			}
+		}
+		for brackets && i < len(k) && k[i] == '[' {
+			end := strings.IndexByte(k[i:], ']')
+			if end < 0 {
+				return nil, ErrFlattenSyntax
+			}
+			index := k[i+1 : i+end]
+			n, err := strconv.Atoi(index)
+			if err != nil || n < 0 || n > maxIndex || strconv.Itoa(n) != index {
+				return nil, ErrFlattenSyntax
+			}
+			path = append(path, n)
+			i += end + 1
+		}
+		if i == len(k) {
+			return path, nil
+		}
+		if !strings.HasPrefix(k[i:], sep) {
+			return nil, ErrFlattenSyntax
+		}
+	}
+}
+
+func (opts FlattenOptions) separator() (string, error) {
+	sep := opts.Separator
+	if sep == "" {
+		sep = "."
+	}
+	if sep[0] == '\\' || (sep[0] == '[' && opts.Brackets) {
+		return "", ErrFlattenSeparator
+	}
+	return sep, nil
+}
@@ -0,0 +1,111 @@
+package amorph_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/clucia/amorph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlatten(t *testing.T) {
+	doc := map[string]interface{}{
+		"config": map[string]interface{}{
+			"webaddresses": []interface{}{"http://a", "http://b"},
+			"empty":        map[string]interface{}{},
+			"none":         []interface{}{},
+		},
+		"a.b": 1.0,
+		"7":   nil,
+	}
+	flat, err := amorph.Flatten(doc, amorph.FlattenOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"config.webaddresses.0": "http://a",
+		"config.webaddresses.1": "http://b",
+		"config.empty":          map[string]interface{}{},
+		"config.none":           []interface{}{},
+		`a\.b`:                  1.0,
+		`\7`:                    nil,
+	}, flat)
+
+	flat, err = amorph.Flatten(doc, amorph.FlattenOptions{Separator: "__", Brackets: true})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"config__webaddresses[0]": "http://a",
+		"config__webaddresses[1]": "http://b",
+		"config__empty":           map[string]interface{}{},
+		"config__none":            []interface{}{},
+		"a.b":                     1.0,
+		"7":                       nil,
+	}, flat)
+
+	// the top of the Amorph
+	flat, err = amorph.Flatten("leaf", amorph.FlattenOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"": "leaf"}, flat)
+	_, err = amorph.Flatten(map[string]interface{}{"": 1.0}, amorph.FlattenOptions{})
+	assert.True(t, errors.Is(err, amorph.ErrFlattenConflict))
+	_, err = amorph.Flatten(map[string]interface{}{}, amorph.FlattenOptions{Separator: `\`})
+	assert.True(t, errors.Is(err, amorph.ErrFlattenSeparator))
+}
+
+func TestFlattenRoundTrip(t *testing.T) {
+	data, err := amorph.NewAmorphFromFile("test.json")
+	assert.Nil(t, err)
+	docs := []amorph.Amorph{
+		data,
+		map[string]interface{}{},
+		[]interface{}{},
+		[]interface{}{[]interface{}{1.0, amorph.NULL}, map[string]interface{}{"": 2.0}},
+		map[string]interface{}{"0": 1.0, "01": "x", "": map[string]interface{}{"": "y"}},
+		map[string]interface{}{`a\`: map[string]interface{}{"[1]": "z", "_": "__", "b__c___": 3.0}},
+	}
+	for _, opts := range []amorph.FlattenOptions{{}, {Brackets: true}, {Separator: "__"}, {Separator: "/", Brackets: true}} {
+		for _, doc := range docs {
+			flat, err := amorph.Flatten(doc, opts)
+			assert.Nil(t, err)
+			ar, err := amorph.Unflatten(flat, opts)
+			assert.Nil(t, err)
+			assert.True(t, amorph.DeepEqual(doc, ar), "%+v %v", opts, flat)
+		}
+	}
+}
+
+func TestUnflatten(t *testing.T) {
+	ar, err := amorph.Unflatten(map[string]interface{}{"a[2]": 1.0, "b.c": "x"}, amorph.FlattenOptions{Brackets: true})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"a": []interface{}{amorph.NULL, amorph.NULL, 1.0},
+		"b": map[string]interface{}{"c": "x"},
+	}, ar)
+
+	ar, err = amorph.Unflatten(map[string]interface{}{}, amorph.FlattenOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{}, ar)
+
+	for _, flat := range []map[string]interface{}{
+		{"a": 1.0, "a.b": 2.0},
+		{"a.0": 1.0, "a.b": 2.0},
+		{"a": map[string]interface{}{}, "a.b": 2.0},
+	} {
+		_, err = amorph.Unflatten(flat, amorph.FlattenOptions{})
+		assert.True(t, errors.Is(err, amorph.ErrFlattenConflict), "%v", flat)
+		var fe *amorph.FlattenError
+		assert.True(t, errors.As(err, &fe))
+		assert.Equal(t, "a", fe.Key)
+	}
+	for _, key := range []string{`a\`, "a.01", "a[", "a[x]", "a[0]b"} {
+		_, err = amorph.Unflatten(map[string]interface{}{key: 1.0}, amorph.FlattenOptions{Brackets: key[1] == '['})
+		assert.True(t, errors.Is(err, amorph.ErrFlattenSyntax), key)
+	}
+	for _, key := range []string{"a.9223372036854775807", "a.9007199254740992", "a[9223372036854775807]", "a.4000000000", "a[4000000000]"} {
+		_, err = amorph.Unflatten(map[string]interface{}{key: 1.0}, amorph.FlattenOptions{Brackets: key[1] == '['})
+		assert.True(t, errors.Is(err, amorph.ErrFlattenSyntax), key)
+		var fe *amorph.FlattenError
+		assert.True(t, errors.As(err, &fe), key)
+	}
+	ar, err = amorph.Unflatten(map[string]interface{}{"a.1000": 1.0}, amorph.FlattenOptions{})
+	assert.Nil(t, err)
+	assert.Len(t, ar.(map[string]interface{})["a"], 1001)
+}
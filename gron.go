@@ -0,0 +1,289 @@
+package amorph
+
+// Copyright 2021 Charles J. Luciano and Scalability
+// Labs LLC. All rights reserved. Use of this source
+// code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Gron writes a as one gron assignment per line, so that it can be
+// searched with grep:
+//
+//	json = [];
+//	json[0] = {};
+//	json[0].config = {};
+//	json[0].config.addr = "10.0.45.17";
+//
+// Maps and slices are assigned {} and [] before the nodes in them, map
+// keys are in sorted order, and a key that isn't an identifier is
+// written as json["a.b"]. NULL holes aren't written.
+func Gron(a Amorph, w io.Writer) error {
+	if err := gronLine(w, Path{}, a); err != nil {
+		return err
+	}
+	return Walk(a, func(iter WalkIter) error {
+		if iter.Len() == 1 {
+			return nil // a leaf at the top, already written
+		}
+		return gronLine(w, IterPath(iter), iter.Top().Value())
+	})
+}
+
+// gronLine writes the assignment of v to path.
+func gronLine(w io.Writer, path Path, v Amorph) error {
+	var value string
+	switch cv := v.(type) {
+	case nullType:
+		return nil
+	case map[string]interface{}:
+		value = "{}"
+	case []interface{}:
+		value = "[]"
+	default:
+		js, err := gronJSON(cv)
+		if err != nil {
+			return err
+		}
+		value = js
+	}
+	var sb strings.Builder
+	sb.WriteString("json")
+	for _, key := range path {
+		switch ckey := key.(type) {
+		case int:
+			sb.WriteString("[" + strconv.Itoa(ckey) + "]")
+		case string:
+			if isGronIdent(ckey) {
+				sb.WriteString("." + ckey)
+				continue
+			}
+			js, err := gronJSON(ckey)
+			if err != nil {
+				return err
+			}
+			sb.WriteString("[" + js + "]")
+		}
+	}
+	_, err := fmt.Fprintf(w, "%s = %s;\n", sb.String(), value)
+	return err
+}
+
+// gronJSON encodes v without escaping "<", ">" and "&", since gron
+// output is for people to read.
+func gronJSON(v interface{}) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+func isGronIdent(key string) bool {
+	for i := 0; i < len(key); i++ {
+		if !isGronIdentByte(key[i], i == 0) {
+			return false
+		}
+	}
+	return key != ""
+}
+
+func isGronIdentByte(c byte, first bool) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (!first && c >= '0' && c <= '9')
+}
+
+// A GronError reports the line of Ungron input it couldn't use.
+type GronError struct {
+	Line int
+	Err  error
+}
+
+func (ge *GronError) Error() string {
+	return "line " + strconv.Itoa(ge.Line) + ": " + ge.Err.Error()
+}
+
+func (ge *GronError) Unwrap() error {
+	return ge.Err
+}
+
+// Ungron rebuilds an Amorph from the assignments Gron writes. The lines
+// can have been filtered: maps and slices that aren't assigned are
+// made when a line needs them, and missing slice indexes are NULL holes.
+// Blank lines are skipped, and no assignments at all gives NULL.
+//
+// So that a short input can't make a huge slice, Ungron fills in at most
+// 65536 holes plus 64 for each byte read.
+//
+// Ungron returns a *GronError wrapping ErrGronSyntax for a line it can't
+// read, or whose index is above 1<<53-1 or needs more holes than that,
+// and ErrGronConflict for one that makes a node both a leaf and a map or
+// slice, both a map and a slice, or two different leaves.
+func Ungron(r io.Reader) (Amorph, error) {
+	var ar Amorph = NULL
+	holes := ungronHoles
+	br := bufio.NewReader(r)
+	for n := 1; ; n++ {
+		line, err := br.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		holes += ungronHolesPerByte * len(line)
+		if strings.TrimSpace(line) != "" {
+			path, v, perr := parseGronLine(line)
+			if perr == nil {
+				ar, perr = ungronSet(ar, path, v, &holes)
+			}
+			if perr != nil {
+				return nil, &GronError{Line: n, Err: perr}
+			}
+		}
+		if err == io.EOF {
+			return ar, nil
+		}
+	}
+}
+
+// The NULL holes Ungron may fill in.
+const (
+	ungronHoles        = 1 << 16
+	ungronHolesPerByte = 64
+)
+
+// ungronSet assigns v at path below node, making the maps and slices on
+// the way. holes is how many more NULL holes it may fill in.
+func ungronSet(node Amorph, path Path, v Amorph, holes *int) (Amorph, error) {
+	if len(path) == 0 {
+		switch cv := v.(type) {
+		case map[string]interface{}:
+			if _, ok := node.(map[string]interface{}); ok && len(cv) == 0 {
+				return node, nil
+			}
+		case []interface{}:
+			if _, ok := node.([]interface{}); ok && len(cv) == 0 {
+				return node, nil
+			}
+		}
+		if node != NULL && !DeepEqual(node, v) {
+			return nil, ErrGronConflict
+		}
+		return v, nil
+	}
+	switch key := path[0].(type) {
+	case int:
+		if node == NULL {
+			node = []interface{}{}
+		}
+		cn, ok := node.([]interface{})
+		if !ok {
+			return nil, ErrGronConflict
+		}
+		if key >= len(cn) {
+			if key-len(cn) > *holes {
+				return nil, ErrGronSyntax
+			}
+			*holes -= key - len(cn)
+			cn = append(cn, NewNullSlice(key+1-len(cn)).([]interface{})...)
+		}
+		child, err := ungronSet(cn[key], path[1:], v, holes)
+		if err != nil {
+			return nil, err
+		}
+		cn[key] = child
+		return cn, nil
+	default:
+		if node == NULL {
+			node = map[string]interface{}{}
+		}
+		cn, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, ErrGronConflict
+		}
+		child, ok := cn[key.(string)]
+		if !ok {
+			child = NULL
+		}
+		child, err := ungronSet(child, path[1:], v, holes)
+		if err != nil {
+			return nil, err
+		}
+		cn[key.(string)] = child
+		return cn, nil
+	}
+}
+
+// parseGronLine reads an assignment written by Gron.
+func parseGronLine(line string) (Path, Amorph, error) {
+	s := strings.TrimSpace(line)
+	if !strings.HasPrefix(s, "json") {
+		return nil, nil, ErrGronSyntax
+	}
+	path := Path{}
+	i := len("json")
+	for i < len(s) && (s[i] == '.' || s[i] == '[') {
+		if s[i] == '.' {
+			start := i + 1
+			for i = start; i < len(s) && isGronIdentByte(s[i], i == start); i++ {
+			}
+			if i == start {
+				return nil, nil, ErrGronSyntax
+			}
+			path = append(path, s[start:i])
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == '"' {
+			end := gronStringEnd(s, i+1)
+			var key string
+			if end < 0 || end == len(s) || s[end] != ']' || json.Unmarshal([]byte(s[i+1:end]), &key) != nil {
+				return nil, nil, ErrGronSyntax
+			}
+			path = append(path, key)
+			i = end + 1
+			continue
+		}
+		end := strings.IndexByte(s[i:], ']')
+		if end < 0 {
+			return nil, nil, ErrGronSyntax
+		}
+		index := s[i+1 : i+end]
+		n, err := strconv.Atoi(index)
+		if err != nil || n < 0 || n > maxIndex || strconv.Itoa(n) != index {
+			return nil, nil, ErrGronSyntax
+		}
+		path = append(path, n)
+		i += end + 1
+	}
+	rest := strings.TrimSpace(s[i:])
+	if !strings.HasPrefix(rest, "=") {
+		return nil, nil, ErrGronSyntax
+	}
+	rest = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(rest[1:]), ";"))
+	var v interface{}
+	if err := json.Unmarshal([]byte(rest), &v); err != nil {
+		return nil, nil, ErrGronSyntax
+	}
+	return path, v, nil
+}
+
+// gronStringEnd returns the index after the JSON string that starts at
+// s[start], or -1 if it isn't closed.
+func gronStringEnd(s string, start int) int {
+	for j := start + 1; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '"':
+			return j + 1
+		}
+	}
+	return -1
+}
@@ -0,0 +1,88 @@
+package amorph_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/clucia/amorph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGron(t *testing.T) {
+	doc, err := amorph.NewAmorphFromString(`{"config": {"addr": "10.0.45.17", "ports": [80, 443], "a.b": true, "tags": []}, "<x>": null}`)
+	assert.Nil(t, err)
+	var buf bytes.Buffer
+	assert.Nil(t, amorph.Gron(doc, &buf))
+	assert.Equal(t, `json = {};
+json["<x>"] = null;
+json.config = {};
+json.config["a.b"] = true;
+json.config.addr = "10.0.45.17";
+json.config.ports = [];
+json.config.ports[0] = 80;
+json.config.ports[1] = 443;
+json.config.tags = [];
+`, buf.String())
+
+	buf.Reset()
+	assert.Nil(t, amorph.Gron("leaf", &buf))
+	assert.Equal(t, "json = \"leaf\";\n", buf.String())
+
+	// NULL holes aren't written
+	buf.Reset()
+	assert.Nil(t, amorph.Gron([]interface{}{amorph.NULL, 1.0}, &buf))
+	assert.Equal(t, "json = [];\njson[1] = 1;\n", buf.String())
+}
+
+func TestUngron(t *testing.T) {
+	data, err := amorph.NewAmorphFromFile("test.json")
+	assert.Nil(t, err)
+	var buf bytes.Buffer
+	assert.Nil(t, amorph.Gron(data, &buf))
+	ar, err := amorph.Ungron(&buf)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(data, ar))
+
+	// filtered lines make the maps and slices they need
+	ar, err = amorph.Ungron(strings.NewReader(`json[1].config["a.b]"][2] = "x";
+
+	json[1].config.addr = 1;`))
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{amorph.NULL, map[string]interface{}{
+		"config": map[string]interface{}{
+			"a.b]": []interface{}{amorph.NULL, amorph.NULL, "x"},
+			"addr": 1.0,
+		},
+	}}, ar)
+
+	ar, err = amorph.Ungron(strings.NewReader(""))
+	assert.Nil(t, err)
+	assert.Equal(t, amorph.NULL, ar)
+
+	for _, in := range []string{
+		"json.a = 1;\njson.a.b = 2;",
+		"json.a = {};\njson.a[0] = 2;",
+		"json.a = 1;\njson.a = 2;",
+	} {
+		_, err = amorph.Ungron(strings.NewReader(in))
+		assert.True(t, errors.Is(err, amorph.ErrGronConflict), in)
+		var ge *amorph.GronError
+		assert.True(t, errors.As(err, &ge))
+		assert.Equal(t, 2, ge.Line)
+	}
+	for _, in := range []string{"jsn = 1;", "json. = 1;", "json[x] = 1;", `json["a] = 1;`, "json.a 1;", "json.a = {;"} {
+		_, err = amorph.Ungron(strings.NewReader(in))
+		assert.True(t, errors.Is(err, amorph.ErrGronSyntax), in)
+	}
+	for _, in := range []string{"json[9223372036854775807] = 1;", "json[9007199254740992] = 1;", "json = [];\njson[4000000000] = 1;"} {
+		_, err = amorph.Ungron(strings.NewReader(in))
+		assert.True(t, errors.Is(err, amorph.ErrGronSyntax), in)
+		var ge *amorph.GronError
+		assert.True(t, errors.As(err, &ge), in)
+	}
+	ar, err = amorph.Ungron(strings.NewReader("json[1000] = 1;"))
+	assert.Nil(t, err)
+	assert.Len(t, ar, 1001)
+}
@@ -0,0 +1,208 @@
+package amorph
+
+// Copyright 2021 Charles J. Luciano and Scalability
+// Labs LLC. All rights reserved. Use of this source
+// code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"math"
+	"sort"
+)
+
+// Node tags keep values of different types from ever hashing alike,
+// e.g. the string "1" and the float64 1.
+const (
+	hashTagNil byte = iota
+	hashTagNull
+	hashTagBool
+	hashTagFloat64
+	hashTagString
+	hashTagSlice
+	hashTagMap
+	hashTagOther
+)
+
+// Hash computes a content address for an Amorph. Two Amorphs that are
+// DeepEqual produce the same Hash. Map keys are hashed in sorted order,
+// so the result does not depend on map iteration order.
+//
+// The hash of a map or slice is computed from the hashes of its elements,
+// which makes it a Merkle hash: see HashTree for a form that keeps the
+// hash of every subtree.
+func Hash(a Amorph) [32]byte {
+	return NewHashTree(a).Sum
+}
+
+// HashTree mirrors the shape of an Amorph and caches the Hash of every
+// subtree. Comparing the Sum of two nodes tells you in O(1) whether the
+// subtrees below them are identical.
+//
+// A HashTree is a snapshot: if the Amorph is modified after the tree is
+// built, the tree must be rebuilt.
+type HashTree struct {
+	Value Amorph               // the subtree this node describes
+	Sum   [32]byte             // the Hash of Value
+	Map   map[string]*HashTree // child nodes when Value is a map
+	Slice []*HashTree          // child nodes when Value is a slice
+}
+
+// NewHashTree hashes every subtree of an Amorph.
+func NewHashTree(a Amorph) *HashTree {
+	ht := &HashTree{Value: a}
+	h := sha256.New()
+	switch ca := a.(type) {
+	case nil:
+		h.Write([]byte{hashTagNil})
+	case nullType:
+		h.Write([]byte{hashTagNull})
+	case bool:
+		h.Write([]byte{hashTagBool})
+		if ca {
+			h.Write([]byte{1})
+		} else {
+			h.Write([]byte{0})
+		}
+	case float64:
+		if ca == 0 {
+			ca = 0 // -0 and 0 are DeepEqual, so hash them alike
+		}
+		h.Write([]byte{hashTagFloat64})
+		writeUint64(h, math.Float64bits(ca))
+	case string:
+		h.Write([]byte{hashTagString})
+		writeString(h, ca)
+	case []interface{}:
+		ht.Slice = make([]*HashTree, len(ca))
+		h.Write([]byte{hashTagSlice})
+		writeUint64(h, uint64(len(ca)))
+		for i, v := range ca {
+			ht.Slice[i] = NewHashTree(v)
+			h.Write(ht.Slice[i].Sum[:])
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(ca))
+		for k := range ca {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		ht.Map = make(map[string]*HashTree, len(ca))
+		h.Write([]byte{hashTagMap})
+		writeUint64(h, uint64(len(ca)))
+		for _, k := range keys {
+			ht.Map[k] = NewHashTree(ca[k])
+			writeString(h, k)
+			h.Write(ht.Map[k].Sum[:])
+		}
+	default:
+		// Other types are compared with reflect.DeepEqual by DeepEqual,
+		// so the best we can do is their type and json encoding.
+		h.Write([]byte{hashTagOther})
+		writeString(h, fmt.Sprintf("%T", a))
+		js, err := json.Marshal(a)
+		if err != nil {
+			js = []byte(fmt.Sprintf("%#v", a))
+		}
+		writeString(h, string(js))
+	}
+	h.Sum(ht.Sum[:0])
+	return ht
+}
+
+func writeUint64(h hash.Hash, v uint64) {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], v)
+	h.Write(buf[:])
+}
+
+func writeString(h hash.Hash, s string) {
+	writeUint64(h, uint64(len(s)))
+	h.Write([]byte(s))
+}
+
+// Lookup finds the node for a subtree. Each key is a string for a map
+// or an int for a slice, the same as WalkPos.Key.
+func (ht *HashTree) Lookup(keys ...interface{}) (*HashTree, bool) {
+	node := ht
+	for _, key := range keys {
+		switch k := key.(type) {
+		case string:
+			child, ok := node.Map[k]
+			if !ok {
+				return nil, false
+			}
+			node = child
+		case int:
+			if k < 0 || k >= len(node.Slice) {
+				return nil, false
+			}
+			node = node.Slice[k]
+		default:
+			return nil, false
+		}
+	}
+	return node, true
+}
+
+// Equal reports whether two HashTrees describe identical Amorphs.
+func (ht *HashTree) Equal(other *HashTree) bool {
+	if ht == nil || other == nil {
+		return ht == other
+	}
+	return ht.Sum == other.Sum
+}
+
+// DiffHashTree is Diff for two HashTrees. Subtrees with equal hashes are
+// skipped without being visited, so diffing two large documents that
+// differ in a few places costs little more than the size of the changes.
+//
+// DiffHashTree supports the same options as Diff.
+func DiffHashTree(tree0, tree1 *HashTree, ops ...int) (patch Patch) {
+	options := 0
+	for _, v := range ops {
+		options = v | options
+	}
+	patch = diffHashTree(tree0, tree1, options)
+	if OptDiffHash&options > 0 {
+		patch = bindPatchSums(patch, tree0.Sum, tree1.Sum)
+	}
+	return patch
+}
+
+func diffHashTree(tree0, tree1 *HashTree, options int) (patch Patch) {
+	if tree0.Equal(tree1) {
+		return nil
+	}
+	switch {
+	case tree0.Map != nil && tree1.Map != nil:
+		return mapDiffElems(tree0.Value.(map[string]interface{}), tree1.Value.(map[string]interface{}),
+			func(k string) Patch {
+				return diffHashTree(tree0.Map[k], tree1.Map[k], options)
+			})
+	case tree0.Slice != nil && tree1.Slice != nil && OptDiffSplice&options > 0:
+		hashes0 := make([][32]byte, len(tree0.Slice))
+		for i, v := range tree0.Slice {
+			hashes0[i] = v.Sum
+		}
+		hashes1 := make([][32]byte, len(tree1.Slice))
+		for j, v := range tree1.Slice {
+			hashes1[j] = v.Sum
+		}
+		return spliceDiffElems(tree0.Value.([]interface{}), tree1.Value.([]interface{}), hashes0, hashes1,
+			func(i, j int) Patch {
+				return diffHashTree(tree0.Slice[i], tree1.Slice[j], options)
+			})
+	case tree0.Slice != nil && tree1.Slice != nil:
+		return sliceDiffElems(tree0.Value.([]interface{}), tree1.Value.([]interface{}),
+			func(i int) Patch {
+				return diffHashTree(tree0.Slice[i], tree1.Slice[i], options)
+			})
+	default:
+		return diff(tree0.Value, tree1.Value, options)
+	}
+}
@@ -0,0 +1,60 @@
+package amorph_test
+
+import (
+	"testing"
+
+	"github.com/clucia/amorph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashOrderIndependent(t *testing.T) {
+	data0, err := amorph.NewAmorphFromString(`{"a": 1, "b": ["x", "y"], "c": {"d": null}}`)
+	assert.Nil(t, err)
+	data1, err := amorph.NewAmorphFromString(`{"c": {"d": null}, "b": ["x", "y"], "a": 1}`)
+	assert.Nil(t, err)
+	assert.Equal(t, amorph.Hash(data0), amorph.Hash(data1))
+
+	data2, err := amorph.NewAmorphFromString(`{"a": 1, "b": ["y", "x"], "c": {"d": null}}`)
+	assert.Nil(t, err)
+	assert.NotEqual(t, amorph.Hash(data0), amorph.Hash(data2))
+
+	assert.NotEqual(t, amorph.Hash("1"), amorph.Hash(1.0))
+	assert.NotEqual(t, amorph.Hash(nil), amorph.Hash(amorph.NULL))
+	assert.NotEqual(t, amorph.Hash(map[string]interface{}{}), amorph.Hash([]interface{}{}))
+}
+
+func TestHashTree(t *testing.T) {
+	data, err := amorph.NewAmorphFromFile("test.json")
+	assert.Nil(t, err)
+	tree := amorph.NewHashTree(data)
+	assert.Equal(t, amorph.Hash(data), tree.Sum)
+
+	addr0, ok := tree.Lookup(0, "config", "webaddresses", 0)
+	assert.True(t, ok)
+	addr1, ok := tree.Lookup(1, "config", "webaddresses", 0)
+	assert.True(t, ok)
+	assert.True(t, addr0.Equal(addr1))
+	assert.Equal(t, "http://www.mydomain.com", addr0.Value)
+
+	_, ok = tree.Lookup(0, "config", "missing")
+	assert.False(t, ok)
+	_, ok = tree.Lookup(5)
+	assert.False(t, ok)
+}
+
+func TestDiffHashTree(t *testing.T) {
+	data, err := amorph.NewAmorphFromFile("test.json")
+	assert.Nil(t, err)
+	node0 := data.([]interface{})[0]
+	node1 := data.([]interface{})[1]
+
+	tree0 := amorph.NewHashTree(node0)
+	tree1 := amorph.NewHashTree(node1)
+	assert.Nil(t, amorph.DiffHashTree(tree0, tree0))
+
+	patch := amorph.DiffHashTree(tree0, tree1)
+	assert.NotNil(t, patch)
+	res, err := amorph.PatchFwd(patch, amorph.DeepCopy(node0))
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(res, node1))
+}
@@ -2,7 +2,7 @@ package amorph
 
 // Intersection produces an Amorph that contains everything common to the
 // two Amorphs
-func mapIntersection(a0, a1 Amorph, options int) (Amorph, error) {
+func mapIntersection(a0, a1 Amorph, op setOp) (Amorph, error) {
 	ar := make(map[string]interface{})
 	a0map := a0.(map[string]interface{})
 	a1map := a1.(map[string]interface{})
@@ -12,7 +12,7 @@ func mapIntersection(a0, a1 Amorph, options int) (Amorph, error) {
 		if !a1ok {
 			continue
 		}
-		arelem, err := intersection(a0elem, a1elem, options)
+		arelem, err := intersection(a0elem, a1elem, op.descend(a0key))
 		if err != nil {
 			return nil, err
 		}
@@ -24,9 +24,15 @@ func mapIntersection(a0, a1 Amorph, options int) (Amorph, error) {
 	return ar, nil
 }
 
-func sliceIntersection(a0, a1 Amorph, options int) (Amorph, error) {
+func sliceIntersection(a0, a1 Amorph, op setOp) (Amorph, error) {
 	a0Slice := a0.([]interface{})
 	a1Slice := a1.([]interface{})
+	switch {
+	case op.key != "":
+		return sliceKeyedIntersection(a0Slice, a1Slice, op)
+	case sliceSetOption(op.options):
+		return sliceSetIntersection(a0Slice, a1Slice, op.options), nil
+	}
 	l0 := len(a0Slice)
 	l1 := len(a1Slice)
 	min := l1
@@ -37,12 +43,12 @@ func sliceIntersection(a0, a1 Amorph, options int) (Amorph, error) {
 	var err error
 	ar := NewNullSlice(min).([]interface{})
 	for i := 0; i < min; i++ {
-		ar[i], err = intersection(a0Slice[i], a1Slice[i], options)
+		ar[i], err = intersection(a0Slice[i], a1Slice[i], op.descend(i))
 		if err != nil {
 			return nil, err
 		}
 	}
-	return ar, nil
+	return fillHoles(ar, op.options), nil
 }
 
 func Intersection(a0, a1 Amorph, ops ...int) (ar Amorph, err error) {
@@ -50,56 +56,134 @@ func Intersection(a0, a1 Amorph, ops ...int) (ar Amorph, err error) {
 	for _, v := range ops {
 		options = v | options
 	}
-	return intersection(a0, a1, options)
+	return intersection(a0, a1, setOp{options: options})
 }
 
-func intersection(a0, a1 Amorph, options int) (Amorph, error) {
-	switch ca0 := a0.(type) {
+func intersection(a0, a1 Amorph, op setOp) (Amorph, error) {
+	switch a0.(type) {
 	case nullType:
 		return NULL, nil
-	case nil:
-		switch a1.(type) {
-		case nil:
-			return nil, nil
-		default:
-			return NULL, nil
-		}
-	case string:
-		switch ca1 := a1.(type) {
-		case string:
-			if ca0 == ca1 {
-				return ca0, nil
-			}
-			return NULL, nil
-		default:
-			return NULL, nil
-		}
-	case float64:
-		switch ca1 := a1.(type) {
-		case float64:
-			if ca0 == ca1 {
-				return ca0, nil
-			}
-			return NULL, nil
-		default:
-			return NULL, nil
-		}
+	case nil, string, float64:
 	case map[string]interface{}:
 		switch a1.(type) {
 		case map[string]interface{}:
-			return mapIntersection(a0, a1, options)
-		default:
-			return NULL, nil
+			return mapIntersection(a0, a1, op)
 		}
 	case []interface{}:
 		switch a1.(type) {
 		case []interface{}:
-			return sliceIntersection(a0, a1, options)
+			return sliceIntersection(a0, a1, op)
+		}
+	default:
+		return nil, pathError(op.path, ErrUnsupportedType)
+	}
+	switch a1.(type) {
+	case nullType:
+		return NULL, nil
+	}
+	if op.leafEqual(a0, a1) {
+		return a0, nil
+	}
+	return NULL, nil
+}
+
+// IntersectionAll produces an Amorph that contains everything common to
+// all of the Amorphs, in a single pass. The result does not depend on
+// the order of the Amorphs, and is the same as nesting Intersection calls.
+//
+// Unlike Intersection, the options come first, as a single int (0 for
+// none), since the Amorphs take the variadic parameter.
+func IntersectionAll(ops int, amorphs ...Amorph) (ar Amorph, err error) {
+	ar, err = intersectionAll(amorphs, ops)
+	return ar, pathError(Path{}, err)
+}
+
+func intersectionAll(vals []Amorph, options int) (Amorph, error) {
+	if len(vals) == 0 {
+		return NULL, nil
+	}
+	maps, slices := 0, 0
+	for _, v := range vals {
+		switch v.(type) {
+		case nullType:
+			return NULL, nil
+		case map[string]interface{}:
+			maps++
+		case []interface{}:
+			slices++
+		case nil, string, float64:
 		default:
+			return nil, ErrUnsupportedType
+		}
+	}
+	switch {
+	case maps == len(vals):
+		return mapIntersectionAll(vals, options)
+	case slices == len(vals):
+		return sliceIntersectionAll(vals, options)
+	case maps > 0 || slices > 0:
+		return NULL, nil
+	}
+	for _, v := range vals[1:] {
+		if v != vals[0] {
 			return NULL, nil
 		}
-	default:
-		return nil, ErrUnsupportedType
 	}
-	return nil, nil
+	return vals[0], nil
+}
+
+func mapIntersectionAll(vals []Amorph, options int) (Amorph, error) {
+	ar := make(map[string]interface{})
+	keyVals := make([]Amorph, len(vals))
+	for k, v0 := range vals[0].(map[string]interface{}) {
+		keyVals[0] = v0
+		common := true
+		for i, v := range vals[1:] {
+			keyVals[i+1], common = v.(map[string]interface{})[k]
+			if !common {
+				break
+			}
+		}
+		if !common {
+			continue
+		}
+		arelem, err := intersectionAll(keyVals, options)
+		if err != nil {
+			return nil, prependPathError(k, err)
+		}
+		if arelem == NULL {
+			continue
+		}
+		ar[k] = arelem
+	}
+	return ar, nil
+}
+
+func sliceIntersectionAll(vals []Amorph, options int) (Amorph, error) {
+	if sliceSetOption(options) {
+		ar := sliceSetUnion(nil, vals[0].([]interface{}), options)
+		for _, v := range vals[1:] {
+			ar = sliceSetIntersection(ar, v.([]interface{}), options)
+		}
+		return ar, nil
+	}
+	min := len(vals[0].([]interface{}))
+	for _, v := range vals[1:] {
+		if l := len(v.([]interface{})); l < min {
+			min = l
+		}
+	}
+	var err error
+	ar := NewNullSlice(min).([]interface{})
+	idxVals := make([]Amorph, len(vals))
+	for i := range ar {
+		for j, v := range vals {
+			idxVals[j] = v.([]interface{})[i]
+		}
+		ar[i], err = intersectionAll(idxVals, options)
+		if err != nil {
+			return nil, prependPathError(i, err)
+		}
+	}
+	return fillHoles(ar, options), nil
 }
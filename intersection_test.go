@@ -148,3 +148,38 @@ func TestIntersectionSlice(t *testing.T) {
 		// OptSliceNotEqual puts the two values in a slice if they're not equal
 	*/
 }
+
+func TestIntersectionAll(t *testing.T) {
+	data0 := map[string]interface{}{
+		"key0": "value0",
+		"key1": "value1",
+		"key2": []interface{}{1.0, 2.0, 3.0},
+	}
+	data1 := map[string]interface{}{
+		"key0": "value0",
+		"key1": "value1",
+		"key2": []interface{}{1.0, 5.0, 3.0},
+	}
+	data2 := map[string]interface{}{
+		"key0": "value0",
+		"key1": "other",
+		"key2": []interface{}{1.0, 2.0},
+	}
+	res := map[string]interface{}{
+		"key0": "value0",
+		"key2": []interface{}{1.0, amorph.NULL},
+	}
+	i, err := amorph.IntersectionAll(0, data0, data1, data2)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(i, res))
+
+	nested, err := amorph.Intersection(data0, data1)
+	assert.Nil(t, err)
+	nested, err = amorph.Intersection(nested, data2)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(i, nested))
+
+	i, err = amorph.IntersectionAll(0, data0, "value0")
+	assert.Nil(t, err)
+	assert.Equal(t, i, amorph.NULL)
+}
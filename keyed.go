@@ -0,0 +1,171 @@
+package amorph
+
+// Copyright 2021 Charles J. Luciano and Scalability
+// Labs LLC. All rights reserved. Use of this source
+// code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// UnionKeyed, IntersectionKeyed and DifferenceKeyed match the elements
+// of slices by an identity field rather than by index: two maps with
+// the same value for key are the same record, wherever they are in
+// their slices. This applies to every slice in the Amorphs.
+//
+// Elements that aren't maps with the key field are matched whole, as
+// with OptSliceSet. If a slice has more than one record with the same
+// key, they are matched in order with the records that have that key
+// in the other slice.
+//
+// The results keep the order of Amorph0 (then Amorph1 for UnionKeyed)
+// and have no NULL holes.
+
+// UnionKeyed is Union with records matched by key. Records with the
+// same key are combined with Union; the others are all kept.
+func UnionKeyed(a0, a1 Amorph, key string, ops ...int) (ar Amorph, err error) {
+	options := 0
+	for _, v := range ops {
+		options = v | options
+	}
+	return union(a0, a1, setOp{options: options, key: key, resolve: unionResolver(options)})
+}
+
+// IntersectionKeyed is Intersection with records matched by key.
+// Records with the same key are combined with Intersection; the others
+// are left out.
+func IntersectionKeyed(a0, a1 Amorph, key string, ops ...int) (ar Amorph, err error) {
+	options := 0
+	for _, v := range ops {
+		options = v | options
+	}
+	return intersection(a0, a1, setOp{options: options, key: key})
+}
+
+// DifferenceKeyed is Difference with records matched by key. A record
+// in the subtrahend removes the whole record with the same key from the
+// minuend, whatever its other fields are.
+//
+// With OptDifferenceMustSubtract, a record or element of the subtrahend
+// that matches nothing in the minuend is an error.
+func DifferenceKeyed(min, sub Amorph, key string, ops ...int) (Amorph, error) {
+	options := 0
+	for _, v := range ops {
+		options = options | v
+	}
+	return difference(min, sub, setOp{options: options, key: key})
+}
+
+// keyedMatch pairs the elements of s0 with the elements of s1. match[i]
+// is the index in s1 of the element matched with s0[i], or -1, and
+// keyed[i] is true if they were matched by key rather than whole.
+// matched[j] is true if s1[j] was matched.
+func keyedMatch(s0, s1 []interface{}, key string) (match []int, keyed []bool, matched []bool) {
+	byKey := make(map[[32]byte][]int)
+	byValue := make(map[[32]byte][]int)
+	for j, v := range s1 {
+		if sum, ok := recordKey(v, key); ok {
+			byKey[sum] = append(byKey[sum], j)
+		} else {
+			sum := Hash(v)
+			byValue[sum] = append(byValue[sum], j)
+		}
+	}
+	match = make([]int, len(s0))
+	keyed = make([]bool, len(s0))
+	matched = make([]bool, len(s1))
+	for i, v := range s0 {
+		match[i] = -1
+		index := byValue
+		sum, ok := recordKey(v, key)
+		if ok {
+			index = byKey
+		} else {
+			sum = Hash(v)
+		}
+		if js := index[sum]; len(js) > 0 {
+			match[i] = js[0]
+			keyed[i] = ok
+			matched[js[0]] = true
+			index[sum] = js[1:]
+		}
+	}
+	return //
+}
+
+// recordKey gets the Hash of the key field of a record.
+func recordKey(v interface{}, key string) ([32]byte, bool) {
+	record, ok := v.(map[string]interface{})
+	if !ok {
+		return [32]byte{}, false
+	}
+	id, ok := record[key]
+	if !ok {
+		return [32]byte{}, false
+	}
+	return Hash(id), true
+}
+
+func sliceKeyedUnion(s0, s1 []interface{}, op setOp) (Amorph, error) {
+	match, keyed, matched := keyedMatch(s0, s1, op.key)
+	ar := make([]interface{}, 0, len(s0)+len(s1))
+	for i, v := range s0 {
+		if keyed[i] {
+			var err error
+			v, err = union(v, s1[match[i]], op.descend(len(ar)))
+			if err != nil {
+				return nil, err
+			}
+		}
+		if v != NULL {
+			ar = append(ar, v)
+		}
+	}
+	for j, v := range s1 {
+		if matched[j] {
+			continue
+		}
+		v, err := union(NULL, v, op.descend(len(ar)))
+		if err != nil {
+			return nil, err
+		}
+		if v != NULL {
+			ar = append(ar, v)
+		}
+	}
+	return ar, nil
+}
+
+func sliceKeyedIntersection(s0, s1 []interface{}, op setOp) (Amorph, error) {
+	match, keyed, _ := keyedMatch(s0, s1, op.key)
+	ar := make([]interface{}, 0)
+	for i, v := range s0 {
+		if match[i] < 0 {
+			continue
+		}
+		if keyed[i] {
+			var err error
+			v, err = intersection(v, s1[match[i]], op.descend(len(ar)))
+			if err != nil {
+				return nil, err
+			}
+		}
+		ar = append(ar, v)
+	}
+	return ar, nil
+}
+
+func sliceKeyedDifference(m, s []interface{}, op setOp) (Amorph, error) {
+	match, _, matched := keyedMatch(m, s, op.key)
+	if OptDifferenceMustSubtract&op.options > 0 {
+		for _, ok := range matched {
+			if !ok {
+				return nil, pathError(op.path, ErrMustSubtract)
+			}
+		}
+	}
+	ar := make([]interface{}, 0, len(m))
+	for i, v := range m {
+		if match[i] < 0 {
+			ar = append(ar, v)
+		}
+	}
+	return ar, nil
+}
@@ -0,0 +1,80 @@
+package amorph_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/clucia/amorph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnionKeyed(t *testing.T) {
+	inventory0, err := amorph.NewAmorphFromFile("test.json")
+	assert.Nil(t, err)
+	inventory1, err := amorph.NewAmorphFromString(`[
+		{
+			"slug": "exam2",
+			"name": "new thing"
+		},
+		{
+			"slug": "exam0",
+			"config": {
+				"addr": "10.0.45.18",
+				"webaddresses": ["http://www.mydomain.com", "http://new.mydomain.com"]
+			}
+		}
+	]`)
+	assert.Nil(t, err)
+
+	u, err := amorph.UnionKeyed(inventory0, inventory1, "slug", amorph.OptUnionSliceResolveAmorph1)
+	assert.Nil(t, err)
+	records := u.([]interface{})
+	assert.Len(t, records, 3)
+	exam0 := records[0].(map[string]interface{})
+	assert.Equal(t, "example thing", exam0["name"])
+	assert.Equal(t, "10.0.45.18", exam0["config"].(map[string]interface{})["addr"])
+	assert.True(t, amorph.DeepEqual(exam0["config"].(map[string]interface{})["webaddresses"], []interface{}{
+		"http://www.mydomain.com",
+		"http://example0.mydomain.com",
+		"http://mydomain.com",
+		"http://new.mydomain.com",
+	}))
+	assert.Equal(t, "exam1", records[1].(map[string]interface{})["slug"])
+	assert.Equal(t, "exam2", records[2].(map[string]interface{})["slug"])
+}
+
+func TestIntersectionKeyed(t *testing.T) {
+	data0 := []interface{}{
+		map[string]interface{}{"id": 1.0, "name": "a", "size": 1.0},
+		map[string]interface{}{"id": 2.0, "name": "b"},
+		"loose",
+	}
+	data1 := []interface{}{
+		"loose",
+		map[string]interface{}{"id": 3.0, "name": "c"},
+		map[string]interface{}{"id": 1.0, "name": "a", "size": 2.0},
+	}
+	res := []interface{}{
+		map[string]interface{}{"id": 1.0, "name": "a"},
+		"loose",
+	}
+	i, err := amorph.IntersectionKeyed(data0, data1, "id")
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(i, res))
+}
+
+func TestDifferenceKeyed(t *testing.T) {
+	inventory, err := amorph.NewAmorphFromFile("test.json")
+	assert.Nil(t, err)
+	remove := []interface{}{
+		map[string]interface{}{"slug": "exam0"},
+	}
+	d, err := amorph.DifferenceKeyed(inventory, remove, "slug", amorph.OptDifferenceMustSubtract)
+	assert.Nil(t, err)
+	assert.Len(t, d, 1)
+	assert.Equal(t, "exam1", d.([]interface{})[0].(map[string]interface{})["slug"])
+
+	remove = append(remove, map[string]interface{}{"slug": "exam9"})
+	_, err = amorph.DifferenceKeyed(inventory, remove, "slug", amorph.OptDifferenceMustSubtract)
+	assert.True(t, errors.Is(err, amorph.ErrMustSubtract))
+}
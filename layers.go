@@ -0,0 +1,177 @@
+package amorph
+
+// Copyright 2021 Charles J. Luciano and Scalability
+// Labs LLC. All rights reserved. Use of this source
+// code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Layers stacks named Amorphs, such as defaults, site, env and user
+// configuration, each layered over the ones before it. The effective
+// Amorph is the Union of the layers, in order, with OptResolveAmorph1:
+// maps and slices are combined, and any other value replaces what the
+// layers below had in the same place.
+//
+// The zero value is an empty stack.
+type Layers struct {
+	names  []string
+	values []Amorph
+}
+
+// Provenance describes where the value at a path in the effective
+// Amorph came from.
+//
+// Layers are the names of the layers that supplied the value. A leaf
+// comes from a single layer; a map or slice from every layer that had
+// one there, since they were combined. Shadowed are the names of the
+// layers whose value at the path was replaced by a later layer, in
+// order.
+type Provenance struct {
+	Value    Amorph
+	Layers   []string
+	Shadowed []string
+}
+
+// Push adds a layer on top of the stack.
+func (l *Layers) Push(name string, a Amorph) {
+	l.names = append(l.names, name)
+	l.values = append(l.values, a)
+}
+
+// Names returns the names of the layers, bottom first.
+func (l *Layers) Names() []string {
+	return append([]string{}, l.names...)
+}
+
+// Effective returns the Union of the layers. It is NULL if there are no
+// layers. Like Union, it shares maps, slices and subtrees with the
+// layers rather than copying them, so changing the result changes the
+// layers too; DeepCopy it first to change it on its own.
+func (l *Layers) Effective() (Amorph, error) {
+	var ar Amorph = NULL
+	for _, v := range l.values {
+		var err error
+		ar, err = Union(ar, v, OptResolveAmorph1)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ar, nil
+}
+
+// Provenance reports which layers supplied the value at path in the
+// effective Amorph, and which they shadowed. ok is false if the
+// effective Amorph has no value at path. Value is shared with the
+// layers, as for Effective.
+func (l *Layers) Provenance(path Path) (p Provenance, ok bool) {
+	// kinds[k] is the kind of value the layers so far have put at
+	// path[:k], and from is the layers that supplied the value at path.
+	kinds := make([]layerKind, len(path)+1)
+	var from, shadowed []int
+	for i, layer := range l.values {
+		v := layer
+		for k := 0; k <= len(path); k++ {
+			kind := kindOf(v)
+			if kind == kindAbsent {
+				break // NULL leaves what the layers below put here
+			}
+			if kinds[k] == kindAbsent || kind != kinds[k] || kind == kindLeaf {
+				// v is the value here now, replacing anything below
+				if kinds[len(path)] != kindAbsent {
+					shadowed = append(shadowed, from...)
+				}
+				from = nil
+				layerKinds(kinds[k:], v, path[k:])
+				if kinds[len(path)] != kindAbsent {
+					from = []int{i}
+				}
+				break
+			}
+			// two maps or two slices are combined
+			if k == len(path) {
+				from = append(from, i)
+				break
+			}
+			var found bool
+			v, found = lookupKey(v, path[k])
+			if !found {
+				break
+			}
+		}
+	}
+	if kinds[len(path)] == kindAbsent {
+		return Provenance{}, false
+	}
+	effective, err := l.Effective()
+	if err != nil {
+		return Provenance{}, false
+	}
+	for _, key := range path {
+		effective, _ = lookupKey(effective, key)
+	}
+	p.Value = effective
+	for _, i := range from {
+		p.Layers = append(p.Layers, l.names[i])
+	}
+	for _, i := range shadowed {
+		p.Shadowed = append(p.Shadowed, l.names[i])
+	}
+	return p, true
+}
+
+type layerKind int
+
+const (
+	kindAbsent layerKind = iota
+	kindMap
+	kindSlice
+	kindLeaf
+)
+
+func kindOf(v Amorph) layerKind {
+	switch v.(type) {
+	case nullType:
+		return kindAbsent
+	case map[string]interface{}:
+		return kindMap
+	case []interface{}:
+		return kindSlice
+	default:
+		return kindLeaf
+	}
+}
+
+// layerKinds fills in the kinds of the values in v along path.
+func layerKinds(kinds []layerKind, v Amorph, path Path) {
+	found := true
+	for k := range kinds {
+		if k > 0 && found {
+			v, found = lookupKey(v, path[k-1])
+		}
+		if !found {
+			kinds[k] = kindAbsent
+			continue
+		}
+		kinds[k] = kindOf(v)
+	}
+}
+
+// lookupKey gets the value of a map key (string) or slice index (int).
+func lookupKey(v Amorph, key interface{}) (Amorph, bool) {
+	switch cv := v.(type) {
+	case map[string]interface{}:
+		k, ok := key.(string)
+		if !ok {
+			return nil, false
+		}
+		val, ok := cv[k]
+		return val, ok
+	case []interface{}:
+		i, ok := key.(int)
+		if !ok || i < 0 || i >= len(cv) {
+			return nil, false
+		}
+		return cv[i], true
+	default:
+		return nil, false
+	}
+}
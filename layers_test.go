@@ -0,0 +1,64 @@
+package amorph_test
+
+import (
+	"testing"
+
+	"github.com/clucia/amorph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLayers(t *testing.T) {
+	var layers amorph.Layers
+	layers.Push("defaults", map[string]interface{}{
+		"port": 80.0,
+		"log":  map[string]interface{}{"level": "info", "file": "/var/log/app"},
+		"tls":  map[string]interface{}{"cert": "default.pem"},
+	})
+	layers.Push("site", map[string]interface{}{
+		"port": 8080.0,
+		"tls":  "off",
+	})
+	layers.Push("env", map[string]interface{}{
+		"log": map[string]interface{}{"level": "debug"},
+		"tls": map[string]interface{}{"key": "env.key"},
+	})
+	layers.Push("user", map[string]interface{}{
+		"port": 9090.0,
+	})
+
+	effective, err := layers.Effective()
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(effective, map[string]interface{}{
+		"port": 9090.0,
+		"log":  map[string]interface{}{"level": "debug", "file": "/var/log/app"},
+		"tls":  map[string]interface{}{"key": "env.key"},
+	}))
+
+	p, ok := layers.Provenance(amorph.Path{"port"})
+	assert.True(t, ok)
+	assert.Equal(t, 9090.0, p.Value)
+	assert.Equal(t, []string{"user"}, p.Layers)
+	assert.Equal(t, []string{"defaults", "site"}, p.Shadowed)
+
+	p, ok = layers.Provenance(amorph.Path{"log", "file"})
+	assert.True(t, ok)
+	assert.Equal(t, []string{"defaults"}, p.Layers)
+	assert.Nil(t, p.Shadowed)
+
+	p, ok = layers.Provenance(amorph.Path{"log"})
+	assert.True(t, ok)
+	assert.Equal(t, []string{"defaults", "env"}, p.Layers)
+
+	p, ok = layers.Provenance(amorph.Path{"tls"})
+	assert.True(t, ok)
+	assert.Equal(t, []string{"env"}, p.Layers)
+	assert.Equal(t, []string{"defaults", "site"}, p.Shadowed)
+
+	_, ok = layers.Provenance(amorph.Path{"tls", "cert"})
+	assert.False(t, ok)
+
+	p, ok = layers.Provenance(amorph.Path{"tls", "key"})
+	assert.True(t, ok)
+	assert.Equal(t, []string{"env"}, p.Layers)
+	assert.Nil(t, p.Shadowed)
+}
@@ -0,0 +1,91 @@
+package amorph
+
+// Copyright 2021 Charles J. Luciano and Scalability
+// Labs LLC. All rights reserved. Use of this source
+// code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+import (
+	"math"
+	"strings"
+)
+
+// A LeafEqual decides whether two values in the same topological
+// position match, for IntersectionFunc and DifferenceFunc. It is called
+// for every pair of values that can't be compared by descending into
+// them: two leaves, or two values of different types. v0 is from
+// Amorph0 (or the minuend) and v1 from Amorph1 (or the subtrahend).
+//
+// Slices compared with OptSliceSet, OptSliceMultiset or by key match
+// their elements with DeepEqual, not with the LeafEqual.
+type LeafEqual func(v0, v1 Amorph) bool
+
+// IntersectionFunc is Intersection with leaves matched by equal rather
+// than ==. Where two leaves match, the result has the value from
+// Amorph0.
+func IntersectionFunc(a0, a1 Amorph, equal LeafEqual, ops ...int) (ar Amorph, err error) {
+	options := 0
+	for _, v := range ops {
+		options = v | options
+	}
+	return intersection(a0, a1, setOp{options: options, equal: equal})
+}
+
+// DifferenceFunc is Difference with leaves matched by equal rather than
+// ==. A value in the minuend is subtracted if it matches the value in
+// the same place in the subtrahend.
+//
+// With AnyLeaf, anything in the subtrahend subtracts whatever is in the
+// same place in the minuend.
+func DifferenceFunc(min, sub Amorph, equal LeafEqual, ops ...int) (Amorph, error) {
+	options := 0
+	for _, v := range ops {
+		options = options | v
+	}
+	return difference(min, sub, setOp{options: options, equal: equal})
+}
+
+// leafEqual matches two values with the LeafEqual, or by default, two
+// leaves of the same type with ==.
+func (op setOp) leafEqual(v0, v1 Amorph) bool {
+	if op.equal != nil {
+		return op.equal(v0, v1)
+	}
+	switch v0.(type) {
+	case nil, string, float64:
+		switch v1.(type) {
+		case nil, string, float64:
+			return v0 == v1
+		}
+	}
+	return false
+}
+
+// AnyLeaf matches any two values.
+func AnyLeaf(v0, v1 Amorph) bool {
+	return true
+}
+
+// EqualFold matches two strings that are equal ignoring case, and any
+// other two values that are DeepEqual.
+func EqualFold(v0, v1 Amorph) bool {
+	s0, ok0 := v0.(string)
+	s1, ok1 := v1.(string)
+	if ok0 && ok1 {
+		return strings.EqualFold(s0, s1)
+	}
+	return DeepEqual(v0, v1)
+}
+
+// Float64Tolerance returns a LeafEqual that matches two numbers no more
+// than tolerance apart, and any other two values that are DeepEqual.
+func Float64Tolerance(tolerance float64) LeafEqual {
+	return func(v0, v1 Amorph) bool {
+		f0, ok0 := v0.(float64)
+		f1, ok1 := v1.(float64)
+		if ok0 && ok1 {
+			return math.Abs(f0-f1) <= tolerance
+		}
+		return DeepEqual(v0, v1)
+	}
+}
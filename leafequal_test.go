@@ -0,0 +1,62 @@
+package amorph_test
+
+import (
+	"testing"
+
+	"github.com/clucia/amorph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIntersectionFunc(t *testing.T) {
+	data0 := map[string]interface{}{
+		"name":  "Alice",
+		"score": 9.99,
+		"tags":  []interface{}{"A", "b"},
+	}
+	data1 := map[string]interface{}{
+		"name":  "ALICE",
+		"score": 10.0,
+		"tags":  []interface{}{"a", "c"},
+	}
+	i, err := amorph.IntersectionFunc(data0, data1, amorph.EqualFold)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(i, map[string]interface{}{
+		"name": "Alice",
+		"tags": []interface{}{"A", amorph.NULL},
+	}))
+
+	i, err = amorph.IntersectionFunc(data0, data1, amorph.Float64Tolerance(0.05))
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(i, map[string]interface{}{
+		"score": 9.99,
+		"tags":  []interface{}{amorph.NULL, amorph.NULL},
+	}))
+}
+
+func TestDifferenceFunc(t *testing.T) {
+	data0 := map[string]interface{}{
+		"key0": "value0",
+		"key1": map[string]interface{}{"inner": "value1"},
+		"key2": []interface{}{"a", "b", "c"},
+		"key3": "value3",
+	}
+	data1 := map[string]interface{}{
+		"key0": "other",
+		"key1": "replaced",
+		"key2": []interface{}{"x", amorph.NULL},
+	}
+	d, err := amorph.DifferenceFunc(data0, data1, amorph.AnyLeaf)
+	assert.Nil(t, err)
+	td, err := amorph.TopoDifference(data0, data1)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(d, td))
+	assert.True(t, amorph.DeepEqual(d, map[string]interface{}{
+		"key2": []interface{}{amorph.NULL, "b", "c"},
+		"key3": "value3",
+	}))
+
+	d, err = amorph.DifferenceFunc(data0, map[string]interface{}{"key0": "VALUE0"}, amorph.EqualFold, amorph.OptDifferenceMustSubtract)
+	assert.Nil(t, err)
+	_, ok := d.(map[string]interface{})["key0"]
+	assert.False(t, ok)
+}
@@ -15,6 +15,18 @@ const (
 	OptDifferenceMustSubtract
 
 	OptTopoDifferenceMustSubtract
+
+	OptDiffHash        // record the Hash of both Amorphs in the Patch
+	OptPatchIgnoreHash // apply a Patch even if the Amorph's Hash doesn't match the one recorded in the Patch
+	OptDiffSplice      // diff slices as sequences of inserts and deletes rather than position by position
+
+	OptSliceSet      // Union, Intersection and Difference treat slices as sets
+	OptSliceMultiset // Union, Intersection and Difference treat slices as multisets
+
+	OptCompactNulls // set operations remove NULL holes from the slices they produce
+	OptNullsToNil   // set operations replace NULL holes with nil in the slices they produce
+
+	OptPointerCreate // Set and Add create the missing maps and slices along a JSON pointer
 )
 
 const (
@@ -1,6 +1,10 @@
 package amorph
 
-import "fmt"
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
 
 // Copyright 2021 Charles J. Luciano and Scalability
 // Labs LLC. All rights reserved. Use of this source
@@ -17,22 +21,94 @@ const (
 
 // ApplyFwd duplicates the input Amorph to the output Amorph with the
 // differences in patch applied
-func PatchFwd(patch Patch, amorphIn Amorph) (absout Amorph, err error) {
+//
+// If the patch was made with OptDiffHash, amorphIn must have the Hash of
+// the Amorph the patch was made from, otherwise ErrPatchBaseMismatch is
+// returned. The OptPatchIgnoreHash option skips this check.
+func PatchFwd(patch Patch, amorphIn Amorph, ops ...int) (absout Amorph, err error) {
+	options := 0
+	for _, v := range ops {
+		options = v | options
+	}
+	if OptPatchIgnoreHash&options == 0 && !checkPatchHash(DirRev, patch, amorphIn) {
+		return nil, ErrPatchBaseMismatch
+	}
 	return apply(DirFwd, patch, amorphIn)
 }
 
-func ApplyFwd(patch Patch, amorphIn Amorph) (absout Amorph, err error) {
-	return apply(DirFwd, patch, amorphIn)
+func ApplyFwd(patch Patch, amorphIn Amorph, ops ...int) (absout Amorph, err error) {
+	return PatchFwd(patch, amorphIn, ops...)
 }
 
 // ApplyFwd duplicates the input Amorph to the output Amorph with the
 // differences in patch REVERSE applied
-func PatchRev(patch Patch, amorphIn Amorph) (amorphOut Amorph, err error) {
+//
+// If the patch was made with OptDiffHash, amorphIn must have the Hash of
+// the Amorph the patch was made to, otherwise ErrPatchTargetMismatch is
+// returned. The OptPatchIgnoreHash option skips this check.
+func PatchRev(patch Patch, amorphIn Amorph, ops ...int) (amorphOut Amorph, err error) {
+	options := 0
+	for _, v := range ops {
+		options = v | options
+	}
+	if OptPatchIgnoreHash&options == 0 && !checkPatchHash(DirFwd, patch, amorphIn) {
+		return nil, ErrPatchTargetMismatch
+	}
 	return apply(DirRev, patch, amorphIn)
 }
 
-func ApplyRev(patch Patch, amorphIn Amorph) (amorphOut Amorph, err error) {
-	return apply(DirRev, patch, amorphIn)
+func ApplyRev(patch Patch, amorphIn Amorph, ops ...int) (amorphOut Amorph, err error) {
+	return PatchRev(patch, amorphIn, ops...)
+}
+
+// bindPatch records the Hash of the Amorphs a patch was made from and to.
+func bindPatch(patch Patch, amorph0, amorph1 Amorph) Patch {
+	return bindPatchSums(patch, Hash(amorph0), Hash(amorph1))
+}
+
+func bindPatchSums(patch Patch, sum0, sum1 [32]byte) Patch {
+	if patch == nil {
+		patch = map[string]interface{}{
+			"typ": "nop",
+		}
+	}
+	patch.(map[string]interface{})["hashRev"] = hex.EncodeToString(sum0[:])
+	patch.(map[string]interface{})["hashFwd"] = hex.EncodeToString(sum1[:])
+	return patch
+}
+
+// PatchHash returns the Hash recorded in a patch for one direction:
+// DirRev for the Amorph the patch was made from, DirFwd for the Amorph
+// it was made to. ok is false if the patch has no such Hash.
+func PatchHash(dir string, patch Patch) (sum [32]byte, ok bool) {
+	pm, ok := patch.(map[string]interface{})
+	if !ok {
+		return sum, false
+	}
+	str, ok := pm["hash"+dir].(string)
+	if !ok {
+		return sum, false
+	}
+	b, err := hex.DecodeString(str)
+	if err != nil || len(b) != len(sum) {
+		return sum, false
+	}
+	copy(sum[:], b)
+	return sum, true
+}
+
+// checkPatchHash reports whether amorphIn matches the hash recorded
+// for dir. Patches without a hash always match.
+func checkPatchHash(dir string, patch Patch, amorphIn Amorph) bool {
+	pm, ok := patch.(map[string]interface{})
+	if !ok {
+		return true
+	}
+	if _, ok = pm["hash"+dir]; !ok {
+		return true
+	}
+	sum, ok := PatchHash(dir, patch)
+	return ok && sum == Hash(amorphIn)
 }
 
 func apply(dir string, patch Patch, amorphIn Amorph) (amorphOut Amorph, err error) {
@@ -41,6 +117,8 @@ func apply(dir string, patch Patch, amorphIn Amorph) (amorphOut Amorph, err erro
 		return nil, fmt.Errorf("unpack " + dir + " error")
 	}
 	switch {
+	case typ == "nil" || typ == "nop":
+		return amorphIn, nil
 	case typ == "string":
 		amorphOut = valX
 		return //
@@ -49,6 +127,8 @@ func apply(dir string, patch Patch, amorphIn Amorph) (amorphOut Amorph, err erro
 		return //
 	case typ == "slice":
 		return sliceApply(dir, patch, amorphIn)
+	case typ == "splice":
+		return spliceApply(dir, patch, amorphIn)
 	case typ == "map":
 		return mapApply(dir, patch, amorphIn)
 	case typ == "raw":
@@ -69,8 +149,8 @@ func rawApply(dir string, patch Patch, amorphIn Amorph) (amorphOut Amorph, err e
 }
 
 // unpack gets the type of patch, lengtn, value, and delete flag for a patch.
-// only slice patches have a length
-// map and slice patches both get their value from valFwd because valFwd
+// slice, splice and map patches have a length
+// map, slice and splice patches all get their value from valFwd because valFwd
 // contains the information needed to patch in both directions.
 func unpack(dir string, ipatch Patch) (
 	typ string,
@@ -99,6 +179,8 @@ func unpack(dir string, ipatch Patch) (
 	case typ == "map":
 		fallthrough
 	case typ == "slice":
+		fallthrough
+	case typ == "splice":
 		f0, ok = patch["valFwd"]
 	default:
 		f0, ok = patch["val"+dir]
@@ -108,7 +190,7 @@ func unpack(dir string, ipatch Patch) (
 	}
 	f2, ok := patch["len"+dir]
 	if ok && f2 != nil {
-		lenX = f2.(int)
+		lenX, _ = patchLen(f2)
 	}
 	ok = true
 	return
@@ -141,9 +223,12 @@ func sliceApply(dir string, ipatch Patch, amorphIn Amorph) (amorphOut Amorph, er
 		}
 		abs2 = abs2[:lenX]
 	}
-	replace := valX.([]Patch)
+	replace, ok := patchList(valX)
+	if !ok {
+		return nil, fmt.Errorf("bad type")
+	}
 	for i := 0; i < lenX; i++ {
-		if replace[i] == nil {
+		if i >= len(replace) || replace[i] == nil {
 			continue
 		}
 		elementPatch, ok := replace[i].(Patch)
@@ -156,8 +241,8 @@ func sliceApply(dir string, ipatch Patch, amorphIn Amorph) (amorphOut Amorph, er
 			}
 			continue
 		}
-		if i < len(amorphIn.([]interface{})) {
-			abs2[i], err = apply(dir, elementPatch, amorphIn.([]interface{})[i])
+		if sliceIn, _ := amorphIn.([]interface{}); i < len(sliceIn) {
+			abs2[i], err = apply(dir, elementPatch, sliceIn[i])
 		} else {
 			abs2[i], err = apply(dir, elementPatch, nil)
 		}
@@ -219,3 +304,19 @@ func mapApply(dir string, ipatch Patch, amorphIn Amorph) (amorphOut Amorph, err
 	}
 	return mapOut, nil
 }
+
+// patchLen reads a length from a patch, which is an int when the patch
+// comes from Diff, but a float64 or json.Number when it has been stored
+// as json and read back.
+func patchLen(v interface{}) (int, bool) {
+	switch cv := v.(type) {
+	case int:
+		return cv, true
+	case float64:
+		return int(cv), true
+	case json.Number:
+		n, err := cv.Int64()
+		return int(n), err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,210 @@
+package amorph_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/clucia/amorph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPatchHash(t *testing.T) {
+	data0, err := amorph.NewAmorphFromString(`{"name": "a", "list": [1, 2]}`)
+	assert.Nil(t, err)
+	data1, err := amorph.NewAmorphFromString(`{"name": "b", "list": [1, 2, 3]}`)
+	assert.Nil(t, err)
+	other, err := amorph.NewAmorphFromString(`{"name": "c"}`)
+	assert.Nil(t, err)
+
+	patch := amorph.Diff(data0, data1, amorph.OptDiffHash)
+	sum, ok := amorph.PatchHash(amorph.DirRev, patch)
+	assert.True(t, ok)
+	assert.Equal(t, amorph.Hash(data0), sum)
+	sum, ok = amorph.PatchHash(amorph.DirFwd, patch)
+	assert.True(t, ok)
+	assert.Equal(t, amorph.Hash(data1), sum)
+
+	_, err = amorph.PatchFwd(patch, amorph.DeepCopy(other))
+	assert.Equal(t, amorph.ErrPatchBaseMismatch, err)
+	_, err = amorph.PatchRev(patch, amorph.DeepCopy(other))
+	assert.Equal(t, amorph.ErrPatchTargetMismatch, err)
+
+	res, err := amorph.PatchFwd(patch, amorph.DeepCopy(data0))
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(res, data1))
+	res, err = amorph.PatchRev(patch, res)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(res, data0))
+
+	_, err = amorph.PatchFwd(patch, amorph.DeepCopy(other), amorph.OptPatchIgnoreHash)
+	assert.Nil(t, err)
+}
+
+func TestPatchHashNoChanges(t *testing.T) {
+	data0, err := amorph.NewAmorphFromString(`{"name": "a"}`)
+	assert.Nil(t, err)
+	assert.Nil(t, amorph.Diff(data0, data0))
+
+	patch := amorph.Diff(data0, data0, amorph.OptDiffHash)
+	assert.NotNil(t, patch)
+	res, err := amorph.PatchFwd(patch, data0)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(res, data0))
+	_, err = amorph.PatchFwd(patch, "something else")
+	assert.Equal(t, amorph.ErrPatchBaseMismatch, err)
+}
+
+func TestInvertPatch(t *testing.T) {
+	data, err := amorph.NewAmorphFromFile("test.json")
+	assert.Nil(t, err)
+	node0 := data.([]interface{})[0]
+	node1 := data.([]interface{})[1]
+
+	patch := amorph.Diff(node0, node1, amorph.OptDiffHash)
+	inv := amorph.InvertPatch(patch)
+	res, err := amorph.PatchFwd(inv, amorph.DeepCopy(node1))
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(res, node0))
+	res, err = amorph.PatchRev(inv, res)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(res, node1))
+
+	assert.True(t, amorph.PatchEqual(patch, amorph.InvertPatch(inv)))
+	assert.Nil(t, amorph.InvertPatch(nil))
+}
+
+func TestNormalizePatch(t *testing.T) {
+	data0, err := amorph.NewAmorphFromString(`{"keep": 1, "list": ["a", "b"], "sub": {"x": 1}}`)
+	assert.Nil(t, err)
+	data1, err := amorph.NewAmorphFromString(`{"keep": 1, "list": ["a", "c", "d"], "sub": {"y": 2}}`)
+	assert.Nil(t, err)
+
+	patch := amorph.Diff(data0, data1)
+	norm := amorph.NormalizePatch(patch)
+	assert.True(t, amorph.PatchEqual(patch, norm))
+
+	// sub had every key replaced, so it collapses to one raw patch
+	sub := norm.(map[string]interface{})["valFwd"].(map[string]interface{})["sub"]
+	assert.Equal(t, "raw", sub.(map[string]interface{})["typ"])
+
+	res, err := amorph.PatchFwd(norm, amorph.DeepCopy(data0))
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(res, data1))
+	res, err = amorph.PatchRev(norm, res)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(res, data0))
+
+	// a patch stored as json decodes with float64 lengths and
+	// []interface{} slice elements
+	stored := amorph.DeepCopy(patch)
+	assert.False(t, amorph.DeepEqual(stored, norm))
+	assert.True(t, amorph.DeepEqual(amorph.NormalizePatch(stored), norm))
+	res, err = amorph.PatchFwd(amorph.NormalizePatch(stored), amorph.DeepCopy(data0))
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(res, data1))
+}
+
+func TestNormalizePatchNoop(t *testing.T) {
+	noop := map[string]interface{}{
+		"typ": "map",
+		"valFwd": map[string]interface{}{
+			"a": map[string]interface{}{
+				"typ":    "string",
+				"valFwd": "same",
+				"valRev": "same",
+			},
+			"b": nil,
+		},
+	}
+	assert.Nil(t, amorph.NormalizePatch(noop))
+
+	bound := amorph.Diff("same", "same", amorph.OptDiffHash)
+	norm := amorph.NormalizePatch(bound)
+	assert.True(t, amorph.DeepEqual(norm, bound))
+}
+
+func TestPatchesConflict(t *testing.T) {
+	base, err := amorph.NewAmorphFromString(`{"name": "a", "config": {"addr": "10.0.0.1", "port": 80}, "list": [1, 2]}`)
+	assert.Nil(t, err)
+	edit0, err := amorph.NewAmorphFromString(`{"name": "b", "config": {"addr": "10.0.0.1", "port": 80}, "list": [1, 2]}`)
+	assert.Nil(t, err)
+	edit1, err := amorph.NewAmorphFromString(`{"name": "a", "config": {"addr": "10.0.0.2", "port": 80}, "list": [1, 2]}`)
+	assert.Nil(t, err)
+	edit2, err := amorph.NewAmorphFromString(`{"name": "a", "config": "gone", "list": [1, 2]}`)
+	assert.Nil(t, err)
+	edit3, err := amorph.NewAmorphFromString(`{"name": "a", "config": {"addr": "10.0.0.1", "port": 80}, "list": [1, 2, 3]}`)
+	assert.Nil(t, err)
+	edit4, err := amorph.NewAmorphFromString(`{"name": "a", "config": {"addr": "10.0.0.1", "port": 80}, "list": [1, 5]}`)
+	assert.Nil(t, err)
+
+	p0 := amorph.Diff(base, edit0)
+	p1 := amorph.Diff(base, edit1)
+	p2 := amorph.Diff(base, edit2)
+	p3 := amorph.Diff(base, edit3)
+	p4 := amorph.Diff(base, edit4)
+
+	paths, commute := amorph.PatchesConflict(p0, p1)
+	assert.True(t, commute)
+	assert.Empty(t, paths)
+
+	paths, commute = amorph.PatchesConflict(p1, p2)
+	assert.False(t, commute)
+	assert.Equal(t, []amorph.Path{{"config"}}, paths)
+
+	paths, commute = amorph.PatchesConflict(p1, p1)
+	assert.True(t, commute)
+	assert.Empty(t, paths)
+
+	paths, commute = amorph.PatchesConflict(p3, p4)
+	assert.False(t, commute)
+	assert.Equal(t, []amorph.Path{{"list"}}, paths)
+
+	paths, commute = amorph.PatchesConflict(
+		amorph.Diff(base, edit0, amorph.OptDiffHash),
+		amorph.Diff(edit1, edit0, amorph.OptDiffHash),
+	)
+	assert.False(t, commute)
+	assert.Equal(t, []amorph.Path{{}}, paths)
+}
+
+func TestPatchStoredAsJSON(t *testing.T) {
+	data0 := map[string]interface{}{"a": 1.0, "b": 2.0}
+	data1 := map[string]interface{}{"a": 1.0, "b": 3.0}
+	js, err := json.Marshal(amorph.Diff(data0, data1))
+	assert.Nil(t, err)
+	stored, err := amorph.NewAmorphFromString(string(js))
+	assert.Nil(t, err)
+
+	res, err := amorph.PatchFwd(stored, amorph.DeepCopy(data0))
+	assert.Nil(t, err)
+	assert.Equal(t, data1, res)
+	res, err = amorph.PatchRev(stored, res)
+	assert.Nil(t, err)
+	assert.Equal(t, data0, res)
+
+	slice0 := []interface{}{"a"}
+	slice1 := []interface{}{"b"}
+	js, err = json.Marshal(amorph.Diff(slice0, slice1))
+	assert.Nil(t, err)
+	stored, err = amorph.NewAmorphFromString(string(js))
+	assert.Nil(t, err)
+
+	res, err = amorph.PatchFwd(stored, amorph.DeepCopy(slice0))
+	assert.Nil(t, err)
+	assert.Equal(t, slice1, res)
+	res, err = amorph.PatchRev(stored, res)
+	assert.Nil(t, err)
+	assert.Equal(t, slice0, res)
+}
+
+func TestPatchSliceToLeaf(t *testing.T) {
+	data0 := []interface{}{"a", "b"}
+	patch := amorph.Diff(data0, "s")
+	assert.Equal(t, "raw", patch.(map[string]interface{})["typ"])
+	res, err := amorph.PatchFwd(patch, amorph.DeepCopy(data0))
+	assert.Nil(t, err)
+	assert.Equal(t, "s", res)
+	res, err = amorph.PatchRev(patch, res)
+	assert.Nil(t, err)
+	assert.Equal(t, data0, res)
+}
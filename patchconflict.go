@@ -0,0 +1,156 @@
+package amorph
+
+// Copyright 2021 Charles J. Luciano and Scalability
+// Labs LLC. All rights reserved. Use of this source
+// code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+import "sort"
+
+// PatchesConflict compares two patches made from the same base and
+// returns the paths where they overlap: places where both patches write
+// different values, or where one replaces or deletes a subtree that the
+// other edits.
+//
+// commute is true when the patches have no conflicts, in which case
+// applying them in either order gives the same result. Two patches that
+// make exactly the same change at a path do not conflict there.
+//
+// A slice whose length is changed by either patch conflicts unless
+// both patches change it to the same length, because slice patches are
+// positional. Splice patches (see OptDiffSplice) only conflict where
+// they change the same element, or insert different values in the
+// same place.
+//
+// If both patches recorded their base with OptDiffHash and the bases
+// differ, the patches conflict at the root.
+func PatchesConflict(patch0, patch1 Patch) (paths []Path, commute bool) {
+	sum0, ok0 := PatchHash(DirRev, patch0)
+	sum1, ok1 := PatchHash(DirRev, patch1)
+	if ok0 && ok1 && sum0 != sum1 {
+		return []Path{{}}, false
+	}
+	patchesConflict(normalizePatch(patch0, false), normalizePatch(patch1, false), []interface{}{}, &paths)
+	return paths, len(paths) == 0
+}
+
+func patchesConflict(patch0, patch1 Patch, path []interface{}, paths *[]Path) {
+	if patch0 == nil || patch1 == nil {
+		return
+	}
+	patchMap0 := patch0.(map[string]interface{})
+	patchMap1 := patch1.(map[string]interface{})
+	typ0 := patchMap0["typ"]
+	typ1 := patchMap1["typ"]
+
+	switch {
+	case typ0 == "map" && typ1 == "map":
+		elems0 := patchMap0["valFwd"].(map[string]interface{})
+		elems1 := patchMap1["valFwd"].(map[string]interface{})
+		keys := make([]string, 0, len(elems0))
+		for k := range elems0 {
+			if _, ok := elems1[k]; ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			patchesConflict(elems0[k], elems1[k], appendPath(path, k), paths)
+		}
+	case (typ0 == "splice" || typ1 == "splice") && isSequencePatch(typ0) && isSequencePatch(typ1):
+		spliceConflicts(spliceOf(patchMap0), spliceOf(patchMap1), path, paths)
+	case typ0 == "slice" && typ1 == "slice":
+		_, lenFwd0, _, _, _ := unpack(DirFwd, patchMap0)
+		_, lenRev0, _, _, _ := unpack(DirRev, patchMap0)
+		_, lenFwd1, _, _, _ := unpack(DirFwd, patchMap1)
+		_, lenRev1, _, _, _ := unpack(DirRev, patchMap1)
+		if (lenFwd0 != lenRev0 || lenFwd1 != lenRev1) && lenFwd0 != lenFwd1 {
+			*paths = append(*paths, path)
+			return
+		}
+		elems0 := patchMap0["valFwd"].([]Patch)
+		elems1 := patchMap1["valFwd"].([]Patch)
+		for i := 0; i < len(elems0) && i < len(elems1); i++ {
+			patchesConflict(elems0[i], elems1[i], appendPath(path, i), paths)
+		}
+	default:
+		// at least one of the patches replaces the whole value here
+		if !DeepEqual(patch0, patch1) {
+			*paths = append(*paths, path)
+		}
+	}
+}
+
+// appendPath returns a new path with key added, leaving path untouched.
+func appendPath(path []interface{}, key interface{}) []interface{} {
+	npath := make([]interface{}, len(path), len(path)+1)
+	copy(npath, path)
+	return append(npath, key)
+}
+
+func isSequencePatch(typ interface{}) bool {
+	return typ == "slice" || typ == "splice"
+}
+
+// spliceOf gets the splice operations for a slice or splice patch.
+func spliceOf(patchMap map[string]interface{}) []Patch {
+	if patchMap["typ"] == "splice" {
+		ops, _ := patchList(patchMap["valFwd"])
+		return ops
+	}
+	ops, _, _ := sliceToSplice(patchMap)
+	return ops
+}
+
+// spliceAt collects the splice operations for one base index.
+type spliceAt struct {
+	inserted []interface{}
+	op       string // "delete", "patch" or ""
+	val      interface{}
+}
+
+func spliceIndex(ops []Patch) map[int]*spliceAt {
+	index := make(map[int]*spliceAt)
+	for _, iop := range ops {
+		op, idx, val := unpackOp(iop)
+		at, ok := index[idx]
+		if !ok {
+			at = &spliceAt{}
+			index[idx] = at
+		}
+		if op == "insert" {
+			vals, _ := val.([]interface{})
+			at.inserted = append(at.inserted, vals...)
+			continue
+		}
+		at.op = op
+		at.val = val
+	}
+	return index
+}
+
+func spliceConflicts(ops0, ops1 []Patch, path []interface{}, paths *[]Path) {
+	index0 := spliceIndex(ops0)
+	index1 := spliceIndex(ops1)
+	idxs := make([]int, 0, len(index0))
+	for idx := range index0 {
+		if _, ok := index1[idx]; ok {
+			idxs = append(idxs, idx)
+		}
+	}
+	sort.Ints(idxs)
+	for _, idx := range idxs {
+		at0 := index0[idx]
+		at1 := index1[idx]
+		switch {
+		case len(at0.inserted) > 0 && len(at1.inserted) > 0 && !DeepEqual(at0.inserted, at1.inserted):
+			// the order of the inserted values is ambiguous
+			*paths = append(*paths, appendPath(path, idx))
+		case at0.op == "patch" && at1.op == "patch":
+			patchesConflict(at0.val, at1.val, appendPath(path, idx), paths)
+		case at0.op != "" && at1.op != "" && at0.op != at1.op:
+			// one patch deletes an element the other changes
+			*paths = append(*paths, appendPath(path, idx))
+		}
+	}
+}
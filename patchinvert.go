@@ -0,0 +1,59 @@
+package amorph
+
+// Copyright 2021 Charles J. Luciano and Scalability
+// Labs LLC. All rights reserved. Use of this source
+// code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+import "strings"
+
+// InvertPatch returns a patch whose Forward direction is the Reverse
+// direction of patch, and vice versa:
+//
+//	PatchFwd(InvertPatch(patch), a) is the same as PatchRev(patch, a)
+//
+// Hashes recorded with OptDiffHash are swapped along with the values.
+// The input patch is not modified.
+func InvertPatch(patch Patch) Patch {
+	patchMap, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	inv := make(map[string]interface{}, len(patchMap))
+	for k, v := range patchMap {
+		switch {
+		case strings.HasSuffix(k, DirFwd):
+			inv[strings.TrimSuffix(k, DirFwd)+DirRev] = v
+		case strings.HasSuffix(k, DirRev):
+			inv[strings.TrimSuffix(k, DirRev)+DirFwd] = v
+		default:
+			inv[k] = v
+		}
+	}
+
+	// map, slice and splice patches keep the element patches in valFwd for
+	// both directions, so those are inverted in place rather than swapped.
+	switch patchMap["typ"] {
+	case "map":
+		delete(inv, "valRev")
+		elems, _ := patchMap["valFwd"].(map[string]interface{})
+		invElems := make(map[string]interface{}, len(elems))
+		for k, v := range elems {
+			invElems[k] = InvertPatch(v)
+		}
+		inv["valFwd"] = invElems
+	case "slice":
+		delete(inv, "valRev")
+		elems, _ := patchList(patchMap["valFwd"])
+		invElems := make([]Patch, len(elems))
+		for i, v := range elems {
+			invElems[i] = InvertPatch(v)
+		}
+		inv["valFwd"] = invElems
+	case "splice":
+		delete(inv, "valRev")
+		ops, _ := patchList(patchMap["valFwd"])
+		inv["valFwd"] = invertSpliceOps(ops)
+	}
+	return inv
+}
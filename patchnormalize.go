@@ -0,0 +1,186 @@
+package amorph
+
+// Copyright 2021 Charles J. Luciano and Scalability
+// Labs LLC. All rights reserved. Use of this source
+// code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+import "strings"
+
+// NormalizePatch returns the canonical form of a patch:
+//
+// Element patches that change nothing are removed, and a patch that
+// changes nothing at all becomes nil.
+//
+// A map patch that replaces every key of the map becomes a single raw
+// patch that replaces the whole map.
+//
+// Values decoded from json are converted back to the types Diff
+// produces (int lengths and indexes, []Patch slice elements), and
+// delete flags that are false are dropped, so two normalized patches
+// that do the same thing are DeepEqual and encode to the same json.
+//
+// Hashes recorded with OptDiffHash are kept. The input patch is not
+// modified.
+func NormalizePatch(patch Patch) Patch {
+	norm := normalizePatch(patch, true)
+	patchMap, ok := patch.(map[string]interface{})
+	if !ok {
+		return norm
+	}
+	for _, dir := range []string{DirFwd, DirRev} {
+		sum, ok := patchMap["hash"+dir]
+		if !ok {
+			continue
+		}
+		if norm == nil {
+			norm = map[string]interface{}{
+				"typ": "nop",
+			}
+		}
+		norm.(map[string]interface{})["hash"+dir] = sum
+	}
+	return norm
+}
+
+// PatchEqual reports whether two patches make the same changes.
+func PatchEqual(patch0, patch1 Patch) bool {
+	return DeepEqual(NormalizePatch(patch0), NormalizePatch(patch1))
+}
+
+// normalizePatch does the work of NormalizePatch, but leaves out the
+// hashes. Collapsing map patches is optional, because it turns an edit
+// of one key into a replacement of the whole map, which is wrong for
+// Rebase and PatchesConflict.
+func normalizePatch(patch Patch, collapse bool) Patch {
+	patchMap, ok := patch.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	norm := make(map[string]interface{}, len(patchMap))
+	for k, v := range patchMap {
+		switch {
+		case strings.HasPrefix(k, "hash"):
+			continue
+		case strings.HasPrefix(k, "len"):
+			if n, ok := patchLen(v); ok {
+				v = n
+			}
+		case strings.HasPrefix(k, "delete"):
+			if del, _ := v.(bool); !del {
+				continue
+			}
+		}
+		norm[k] = v
+	}
+	deleteFwd, _ := norm["deleteFwd"].(bool)
+	deleteRev, _ := norm["deleteRev"].(bool)
+
+	switch norm["typ"] {
+	case "string", "float64":
+		if DeepEqual(norm["valFwd"], norm["valRev"]) {
+			return nil
+		}
+	case "raw":
+		if deleteFwd {
+			delete(norm, "valFwd")
+		}
+		if deleteRev {
+			delete(norm, "valRev")
+		}
+		if deleteFwd == deleteRev && DeepEqual(norm["valFwd"], norm["valRev"]) {
+			return nil
+		}
+	case "map":
+		elems, _ := norm["valFwd"].(map[string]interface{})
+		normElems := make(map[string]interface{}, len(elems))
+		for k, v := range elems {
+			if elem := normalizePatch(v, collapse); elem != nil {
+				normElems[k] = elem
+			}
+		}
+		if len(normElems) == 0 {
+			return nil
+		}
+		norm["valFwd"] = normElems
+		if collapse {
+			return collapseMapPatch(norm)
+		}
+	case "slice":
+		elems, _ := patchList(norm["valFwd"])
+		normElems := make([]Patch, len(elems))
+		prune := norm["lenFwd"] == norm["lenRev"]
+		for i, v := range elems {
+			normElems[i] = normalizePatch(v, collapse)
+			if normElems[i] != nil {
+				prune = false
+			}
+		}
+		if prune {
+			return nil
+		}
+		norm["valFwd"] = normElems
+	case "splice":
+		ops, _ := patchList(norm["valFwd"])
+		normOps := make([]Patch, 0, len(ops))
+		for _, iop := range ops {
+			op, idx, val := unpackOp(iop)
+			switch op {
+			case "insert":
+				vals, _ := val.([]interface{})
+				if len(vals) == 0 {
+					continue
+				}
+				normOps = append(normOps, spliceInsertOp(idx, vals))
+			case "delete":
+				normOps = append(normOps, spliceDeleteOp(idx, val))
+			case "patch":
+				if elem := normalizePatch(val, collapse); elem != nil {
+					normOps = append(normOps, splicePatchOp(idx, elem))
+				}
+			}
+		}
+		if len(normOps) == 0 && norm["lenFwd"] == norm["lenRev"] {
+			return nil
+		}
+		norm["valFwd"] = normOps
+	case "nop":
+		return nil
+	}
+	return norm
+}
+
+// collapseMapPatch turns a map patch into a raw patch if it has an
+// element patch for every key on both sides, so that the whole map can
+// be rebuilt from the patch alone.
+func collapseMapPatch(norm map[string]interface{}) Patch {
+	lenFwd, okFwd := norm["lenFwd"].(int)
+	lenRev, okRev := norm["lenRev"].(int)
+	if !okFwd || !okRev {
+		return norm
+	}
+	valFwd := make(map[string]interface{})
+	valRev := make(map[string]interface{})
+	for k, v := range norm["valFwd"].(map[string]interface{}) {
+		elem := v.(map[string]interface{})
+		switch elem["typ"] {
+		case "raw", "string", "float64":
+		default:
+			return norm
+		}
+		if del, _ := elem["deleteFwd"].(bool); !del {
+			valFwd[k] = elem["valFwd"]
+		}
+		if del, _ := elem["deleteRev"].(bool); !del {
+			valRev[k] = elem["valRev"]
+		}
+	}
+	if len(valFwd) != lenFwd || len(valRev) != lenRev {
+		return norm
+	}
+	return map[string]interface{}{
+		"typ":    "raw",
+		"valFwd": valFwd,
+		"valRev": valRev,
+	}
+}
@@ -7,16 +7,18 @@ package amorph
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 )
 
 // PatchStringer converts a patch to a text representation
 // mainly for debugging purposes
 func PatchStringer(patch Patch) string {
-	return describe(patch, "") + "\n"
+	return describe(patch, Path{}) + "\n"
 }
 
-func describe(patch Patch, indent string) (s string) {
+func describe(patch Patch, path Path) (s string) {
+	indent := path.String()
 	var typ string
 	var ok bool
 	typ, _, _, _, ok = unpack(DirFwd, patch)
@@ -26,22 +28,27 @@ func describe(patch Patch, indent string) (s string) {
 	switch {
 	case patch == nil:
 		return indent + "nil\n"
+	case typ == "nop":
+		return indent + " typ = nop\n"
 	case typ == "raw":
-		return rawDescribe(patch, indent)
+		return rawDescribe(patch, path)
 	case typ == "float64":
-		return float64Describe(patch, indent)
+		return float64Describe(patch, path)
 	case typ == "string":
-		return stringDescribe(patch, indent)
+		return stringDescribe(patch, path)
 	case typ == "slice":
-		return sliceDescribe(patch, indent)
+		return sliceDescribe(patch, path)
+	case typ == "splice":
+		return spliceDescribe(patch, path)
 	case typ == "map":
-		return mapDescribe(patch, indent)
+		return mapDescribe(patch, path)
 	default:
 		panic("Malformed patch")
 	}
 }
 
-func mapDescribe(patch Patch, indent string) (s string) {
+func mapDescribe(patch Patch, path Path) (s string) {
+	indent := path.String()
 	var typ string
 	var valFwd interface{}
 	var ok bool
@@ -58,14 +65,19 @@ func mapDescribe(patch Patch, indent string) (s string) {
 		return //
 	}
 	s += indent + " typ = " + typ + "\n"
-	for k, v := range patchMap {
-		istr := indent + "." + k
-		s += describe(v, istr)
+	keys := make([]string, 0, len(patchMap))
+	for k := range patchMap {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		s += describe(patchMap[k], path.Append(k))
 	}
 	return //
 }
 
-func sliceDescribe(patch Patch, indent string) (s string) {
+func sliceDescribe(patch Patch, path Path) (s string) {
+	indent := path.String()
 	var typ string
 	var valFwd interface{}
 	var ok bool
@@ -83,14 +95,44 @@ func sliceDescribe(patch Patch, indent string) (s string) {
 		return //
 	}
 	for i, v := range patchSlice {
-		istr := indent + "[" + strconv.FormatInt(int64(i), 10) + "]"
+		s += describe(v, path.Append(i))
+	}
+	return //
+}
 
-		s += describe(v, istr)
+func spliceDescribe(patch Patch, path Path) (s string) {
+	indent := path.String()
+	var valFwd interface{}
+	var ok bool
+	_, _, valFwd, _, ok = unpack(DirFwd, patch)
+	if !ok {
+		return "error in describe"
+	}
+	ops, ok := patchList(valFwd)
+	if !ok {
+		panic("Malformed patch")
+	}
+	if len(ops) == 0 {
+		s += indent + "Empty splicePatch\n"
+		return //
+	}
+	for _, iop := range ops {
+		op, idx, val := unpackOp(iop)
+		istr := path.Append(idx).String()
+		switch op {
+		case "insert":
+			s += istr + " insert " + fmt.Sprintf("%v", val) + "\n"
+		case "delete":
+			s += istr + " delete " + fmt.Sprintf("%v", val) + "\n"
+		case "patch":
+			s += describe(val, path.Append(idx))
+		}
 	}
 	return //
 }
 
-func float64Describe(patch Patch, indent string) (s string) {
+func float64Describe(patch Patch, path Path) (s string) {
+	indent := path.String()
 	var typ0, typ1 string
 	var valFwd, valRev interface{}
 	var deleteFwd, deleteRev bool
@@ -116,7 +158,8 @@ func float64Describe(patch Patch, indent string) (s string) {
 	return //
 }
 
-func stringDescribe(patch Patch, indent string) (s string) {
+func stringDescribe(patch Patch, path Path) (s string) {
+	indent := path.String()
 	var typ0, typ1 string
 	var valFwd, valRev interface{}
 	var deleteFwd, deleteRev bool
@@ -142,7 +185,8 @@ func stringDescribe(patch Patch, indent string) (s string) {
 	return //
 }
 
-func rawDescribe(patch Patch, indent string) (s string) {
+func rawDescribe(patch Patch, path Path) (s string) {
+	indent := path.String()
 	var typ0, typ1 string
 	var valFwd, valRev interface{}
 	var deleteFwd, deleteRev bool
@@ -0,0 +1,174 @@
+package amorph
+
+// Copyright 2021 Charles J. Luciano and Scalability
+// Labs LLC. All rights reserved. Use of this source
+// code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+import (
+	"strconv"
+	"strings"
+)
+
+// A Path is the location of a node in an Amorph: a list of map keys
+// (string) and slice indexes (int), starting from the top. The empty
+// Path is the whole Amorph.
+//
+// The String form writes each map key as "." and the key, and each
+// slice index in brackets, as in ".config.webaddresses[1]". A ".", "["
+// or backslash in a key is escaped with a backslash.
+type Path []interface{}
+
+// maxIndex is the largest slice index that Ungron and Unflatten read,
+// the largest integer that I-JSON numbers hold exactly.
+const maxIndex = 1<<53 - 1
+
+// String returns the Path in the form read by ParsePath. The empty Path
+// is "".
+func (p Path) String() string {
+	var sb strings.Builder
+	for _, key := range p {
+		switch ckey := key.(type) {
+		case int:
+			sb.WriteString("[" + strconv.Itoa(ckey) + "]")
+		case string:
+			sb.WriteByte('.')
+			for i := 0; i < len(ckey); i++ {
+				if c := ckey[i]; c == '.' || c == '[' || c == '\\' {
+					sb.WriteByte('\\')
+				}
+				sb.WriteByte(ckey[i])
+			}
+		}
+	}
+	return sb.String()
+}
+
+// ParsePath reads a Path written by String. It returns ErrPathSyntax if
+// s isn't one.
+func ParsePath(s string) (Path, error) {
+	p := Path{}
+	for i := 0; i < len(s); {
+		switch s[i] {
+		case '.':
+			var key strings.Builder
+			for i++; i < len(s) && s[i] != '.' && s[i] != '['; i++ {
+				if s[i] == '\\' {
+					if i+1 == len(s) {
+						return nil, ErrPathSyntax
+					}
+					i++
+				}
+				key.WriteByte(s[i])
+			}
+			p = append(p, key.String())
+		case '[':
+			end := strings.IndexByte(s[i:], ']')
+			if end < 0 {
+				return nil, ErrPathSyntax
+			}
+			index := s[i+1 : i+end]
+			n, err := strconv.Atoi(index)
+			if err != nil || n < 0 || strconv.Itoa(n) != index {
+				return nil, ErrPathSyntax
+			}
+			p = append(p, n)
+			i += end + 1
+		default:
+			return nil, ErrPathSyntax
+		}
+	}
+	return p, nil
+}
+
+// JSONPointer returns the Path as an RFC 6901 JSON pointer, for use with
+// Get, Set, Add and Delete.
+func (p Path) JSONPointer() string {
+	tokens := make([]string, len(p))
+	for i, key := range p {
+		switch ckey := key.(type) {
+		case int:
+			tokens[i] = strconv.Itoa(ckey)
+		case string:
+			tokens[i] = ckey
+		}
+	}
+	return formatPointer(tokens)
+}
+
+// Parent returns the Path without its last key. The parent of the empty
+// Path is the empty Path.
+func (p Path) Parent() Path {
+	if len(p) == 0 {
+		return Path{}
+	}
+	return append(Path{}, p[:len(p)-1]...)
+}
+
+// Append returns a new Path with keys added to the end of p.
+func (p Path) Append(keys ...interface{}) Path {
+	np := make(Path, 0, len(p)+len(keys))
+	np = append(np, p...)
+	return append(np, keys...)
+}
+
+// Compare orders Paths key by key, with slice indexes before map keys
+// and a Path before the Paths below it. It returns -1, 0 or 1.
+func (p Path) Compare(other Path) int {
+	switch c := comparePaths(p, other); {
+	case c < 0:
+		return -1
+	case c > 0:
+		return 1
+	}
+	return 0
+}
+
+// Equal reports whether two Paths have the same keys.
+func (p Path) Equal(other Path) bool {
+	return comparePaths(p, other) == 0
+}
+
+// HasPrefix reports whether p is prefix or a Path below it.
+func (p Path) HasPrefix(prefix Path) bool {
+	return len(p) >= len(prefix) && comparePaths(p[:len(prefix)], prefix) == 0
+}
+
+// A PathError is an error at a Path in an Amorph. The set operations
+// return one, wrapping ErrMustSubtract, ErrConflict and the like, so
+// that the caller can tell where the problem is; use errors.Is to check
+// for the wrapped error.
+type PathError struct {
+	Path Path
+	Err  error
+}
+
+func (pe *PathError) Error() string {
+	if len(pe.Path) == 0 {
+		return pe.Err.Error()
+	}
+	return pe.Path.String() + ": " + pe.Err.Error()
+}
+
+func (pe *PathError) Unwrap() error {
+	return pe.Err
+}
+
+// pathError wraps err in a *PathError for path, unless it already is
+// one.
+func pathError(path Path, err error) error {
+	if _, ok := err.(*PathError); ok || err == nil {
+		return err
+	}
+	return &PathError{Path: append(Path{}, path...), Err: err}
+}
+
+// prependPathError adds key to the front of the Path of an error
+// returned from below key, for the operations that don't keep track of
+// their Path on the way down.
+func prependPathError(key interface{}, err error) error {
+	if pe, ok := err.(*PathError); ok {
+		return &PathError{Path: append(Path{key}, pe.Path...), Err: pe.Err}
+	}
+	return &PathError{Path: Path{key}, Err: err}
+}
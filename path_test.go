@@ -0,0 +1,126 @@
+package amorph_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/clucia/amorph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPath(t *testing.T) {
+	p := amorph.Path{"config", "webaddresses", 1}
+	assert.Equal(t, ".config.webaddresses[1]", p.String())
+	assert.Equal(t, "/config/webaddresses/1", p.JSONPointer())
+	assert.Equal(t, amorph.Path{"config", "webaddresses"}, p.Parent())
+	assert.Equal(t, amorph.Path{}, amorph.Path{}.Parent())
+	assert.Equal(t, amorph.Path{"config", "webaddresses", 1, "x"}, p.Append("x"))
+	assert.Equal(t, amorph.Path{"config", "webaddresses", 1}, p) // unchanged
+
+	for _, p := range []amorph.Path{
+		{},
+		{""},
+		{0, 10},
+		{"a.b", "c[0]", `d\e`, "/~"},
+	} {
+		parsed, err := amorph.ParsePath(p.String())
+		assert.Nil(t, err)
+		assert.Equal(t, p, parsed, p.String())
+	}
+	for _, s := range []string{"a", ".a[", ".a[-1]", ".a[01]", ".a[x]", `.a\`} {
+		_, err := amorph.ParsePath(s)
+		assert.Equal(t, amorph.ErrPathSyntax, err, s)
+	}
+
+	assert.Equal(t, -1, amorph.Path{0}.Compare(amorph.Path{"a"}))
+	assert.Equal(t, 1, amorph.Path{"a", 1}.Compare(amorph.Path{"a"}))
+	assert.Equal(t, 0, amorph.Path{"a", 1}.Compare(amorph.Path{"a", 1}))
+	assert.True(t, amorph.Path{"a", 1}.Equal(amorph.Path{"a", 1}))
+	assert.True(t, p.HasPrefix(amorph.Path{"config"}))
+	assert.True(t, p.HasPrefix(amorph.Path{}))
+	assert.False(t, p.HasPrefix(amorph.Path{"webaddresses"}))
+}
+
+func TestWalkIterPath(t *testing.T) {
+	data, err := amorph.NewAmorphFromString(`{"a": [1, {"b": "x"}], "c": "y"}`)
+	assert.Nil(t, err)
+	paths := make([]string, 0)
+	err = amorph.Walk(data, func(iter amorph.WalkIter) error {
+		paths = append(paths, amorph.IterPath(iter).String())
+		return nil
+	})
+	assert.Nil(t, err)
+	// every node once, the leaves included
+	assert.Equal(t, []string{".a", ".a[0]", ".a[1]", ".a[1].b", ".c"}, paths)
+
+	paths = paths[:0]
+	err = amorph.Walk("leaf", func(iter amorph.WalkIter) error {
+		paths = append(paths, amorph.IterPath(iter).String())
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{""}, paths)
+}
+
+// plainIter hides the Path method, as a WalkIter from outside the
+// package would.
+type plainIter struct {
+	amorph.WalkIter
+}
+
+func TestIterPathPlain(t *testing.T) {
+	data, err := amorph.NewAmorphFromString(`{"a": [1, {"b": "x"}], "c": "y"}`)
+	assert.Nil(t, err)
+	err = amorph.Walk(data, func(iter amorph.WalkIter) error {
+		_, ok := amorph.WalkIter(plainIter{iter}).(amorph.PathWalkIter)
+		assert.False(t, ok)
+		assert.Equal(t, amorph.IterPath(iter), amorph.IterPath(plainIter{iter}))
+		return nil
+	})
+	assert.Nil(t, err)
+}
+
+func TestPathError(t *testing.T) {
+	min := map[string]interface{}{"a": []interface{}{"x", "y"}}
+	sub := map[string]interface{}{"a": []interface{}{"x", "z"}}
+
+	_, err := amorph.Difference(min, sub, amorph.OptDifferenceMustSubtract)
+	var pe *amorph.PathError
+	assert.True(t, errors.As(err, &pe))
+	assert.Equal(t, amorph.Path{"a", 1}, pe.Path)
+	assert.True(t, errors.Is(err, amorph.ErrMustSubtract))
+	assert.Equal(t, ".a[1]: can't subtract", err.Error())
+
+	_, err = amorph.DifferenceAll(amorph.OptDifferenceMustSubtract, min, sub)
+	assert.True(t, errors.As(err, &pe))
+	assert.Equal(t, amorph.Path{"a", 1}, pe.Path)
+
+	_, err = amorph.TopoDifference(min, map[string]interface{}{"b": 1.0}, amorph.OptTopoDifferenceMustSubtract)
+	assert.True(t, errors.As(err, &pe))
+	assert.Equal(t, amorph.Path{"b"}, pe.Path)
+
+	_, err = amorph.UnionResolve(min, sub, amorph.ResolveFail)
+	assert.True(t, errors.As(err, &pe))
+	assert.Equal(t, amorph.Path{"a", 1}, pe.Path)
+	assert.True(t, errors.Is(err, amorph.ErrConflict))
+
+	// errors at the top have an empty Path
+	_, err = amorph.UnionResolve("x", "y", amorph.ResolveFail)
+	assert.True(t, errors.As(err, &pe))
+	assert.Equal(t, amorph.Path{}, pe.Path)
+	assert.Equal(t, "conflicting values", err.Error())
+}
+
+func TestRebasePathError(t *testing.T) {
+	base, err := amorph.NewAmorphFromString(`{"list": [{"k": 1}, {"k": 2}]}`)
+	assert.Nil(t, err)
+	edited, err := amorph.NewAmorphFromString(`{"list": [{"k": 1}, {"k": 3}]}`)
+	assert.Nil(t, err)
+	deleted, err := amorph.NewAmorphFromString(`{"list": [{"k": 1}]}`)
+	assert.Nil(t, err)
+
+	_, err = amorph.Rebase(amorph.Diff(base, edited, amorph.OptDiffSplice), amorph.Diff(base, deleted, amorph.OptDiffSplice))
+	var pe *amorph.PathError
+	assert.True(t, errors.As(err, &pe))
+	assert.Equal(t, amorph.Path{"list", 1}, pe.Path)
+}
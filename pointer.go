@@ -0,0 +1,272 @@
+package amorph
+
+// Copyright 2021 Charles J. Luciano and Scalability
+// Labs LLC. All rights reserved. Use of this source
+// code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Get, Exists, Set, Add and Delete address a node of an Amorph with an
+// RFC 6901 JSON pointer, such as "/config/webaddresses/1". Each
+// reference token is a map key or a slice index, with "~1" standing for
+// "/" and "~0" for "~" in a key. "" is the whole Amorph, and "-" is the
+// (nonexistent) element after the end of a slice.
+//
+// Set, Add and Delete change the maps of the Amorph in place, but a
+// slice that grows or shrinks is replaced, so use the Amorph they
+// return.
+//
+// The errors are a *PointerError wrapping ErrPointerSyntax,
+// ErrPointerNotFound, ErrPointerIndex or ErrPointerLeaf.
+
+// A PointerError reports the part of a JSON pointer, up to and including
+// the reference token, that couldn't be followed.
+type PointerError struct {
+	Pointer string // the whole pointer
+	Prefix  string // the pointer up to the token that failed
+	Err     error
+}
+
+func (pe *PointerError) Error() string {
+	return strconv.Quote(pe.Prefix) + ": " + pe.Err.Error()
+}
+
+func (pe *PointerError) Unwrap() error {
+	return pe.Err
+}
+
+// Get returns the value the pointer refers to.
+func Get(a Amorph, pointer string) (Amorph, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	v := a
+	for n, token := range tokens {
+		v, err = pointerStep(v, token)
+		if err != nil {
+			return nil, pointerError(pointer, tokens[:n+1], err)
+		}
+	}
+	return v, nil
+}
+
+// Exists reports whether the pointer refers to a value.
+func Exists(a Amorph, pointer string) bool {
+	_, err := Get(a, pointer)
+	return err == nil
+}
+
+// Set replaces the value the pointer refers to with v. A map key that
+// doesn't exist is added, and "-" or the index one past the end appends
+// to a slice; any other slice index must already exist.
+//
+// With OptPointerCreate, missing map keys along the pointer are filled
+// in with new maps, or new slices where the next token is "-".
+func Set(a Amorph, pointer string, v Amorph, ops ...int) (Amorph, error) {
+	return pointerUpdate(a, pointer, ops, func(parent Amorph, token string) (Amorph, error) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			p[token] = v
+			return p, nil
+		case []interface{}:
+			i, err := sliceIndex(token, len(p), true)
+			if err != nil {
+				return nil, err
+			}
+			if i == len(p) {
+				return append(p, v), nil
+			}
+			p[i] = v
+			return p, nil
+		default:
+			return nil, ErrPointerLeaf
+		}
+	}, v)
+}
+
+// Add is the "add" operation of RFC 6902 JSON Patch: it is Set, except
+// that a slice index inserts v before the element there (or at the end)
+// instead of replacing it. OptPointerCreate is the same as for Set.
+func Add(a Amorph, pointer string, v Amorph, ops ...int) (Amorph, error) {
+	return pointerUpdate(a, pointer, ops, func(parent Amorph, token string) (Amorph, error) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			p[token] = v
+			return p, nil
+		case []interface{}:
+			i, err := sliceIndex(token, len(p), true)
+			if err != nil {
+				return nil, err
+			}
+			ar := make([]interface{}, 0, len(p)+1)
+			ar = append(ar, p[:i]...)
+			ar = append(ar, v)
+			return append(ar, p[i:]...), nil
+		default:
+			return nil, ErrPointerLeaf
+		}
+	}, v)
+}
+
+// Delete removes the value the pointer refers to, which must exist.
+// Deleting a slice element moves the ones after it down. Deleting ""
+// returns NULL.
+func Delete(a Amorph, pointer string) (Amorph, error) {
+	return pointerUpdate(a, pointer, nil, func(parent Amorph, token string) (Amorph, error) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			if _, ok := p[token]; !ok {
+				return nil, ErrPointerNotFound
+			}
+			delete(p, token)
+			return p, nil
+		case []interface{}:
+			i, err := sliceIndex(token, len(p), false)
+			if err != nil {
+				return nil, err
+			}
+			ar := make([]interface{}, 0, len(p)-1)
+			ar = append(ar, p[:i]...)
+			return append(ar, p[i+1:]...), nil
+		default:
+			return nil, ErrPointerLeaf
+		}
+	}, NULL)
+}
+
+// pointerUpdate follows the pointer to the parent of the node it refers
+// to and changes the parent with update. root is the result for "".
+func pointerUpdate(a Amorph, pointer string, ops []int, update func(parent Amorph, token string) (Amorph, error), root Amorph) (Amorph, error) {
+	options := 0
+	for _, v := range ops {
+		options = v | options
+	}
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return root, nil
+	}
+	return pointerUpdateAt(a, tokens, 0, options, pointer, update)
+}
+
+func pointerUpdateAt(v Amorph, tokens []string, n, options int, pointer string, update func(parent Amorph, token string) (Amorph, error)) (Amorph, error) {
+	if n == len(tokens)-1 {
+		ar, err := update(v, tokens[n])
+		if err != nil {
+			return nil, pointerError(pointer, tokens, err)
+		}
+		return ar, nil
+	}
+	child, err := pointerStep(v, tokens[n])
+	if err == ErrPointerNotFound && OptPointerCreate&options > 0 {
+		child, err = map[string]interface{}{}, nil
+		if tokens[n+1] == "-" {
+			child = []interface{}{}
+		}
+	}
+	if err != nil {
+		return nil, pointerError(pointer, tokens[:n+1], err)
+	}
+	child, err = pointerUpdateAt(child, tokens, n+1, options, pointer, update)
+	if err != nil {
+		return nil, err
+	}
+	switch cv := v.(type) {
+	case map[string]interface{}:
+		cv[tokens[n]] = child
+	case []interface{}:
+		i, _ := sliceIndex(tokens[n], len(cv), false)
+		cv[i] = child
+	}
+	return v, nil
+}
+
+// pointerStep gets the value of a reference token in v.
+func pointerStep(v Amorph, token string) (Amorph, error) {
+	switch cv := v.(type) {
+	case map[string]interface{}:
+		val, ok := cv[token]
+		if !ok {
+			return nil, ErrPointerNotFound
+		}
+		return val, nil
+	case []interface{}:
+		i, err := sliceIndex(token, len(cv), false)
+		if err != nil {
+			return nil, err
+		}
+		return cv[i], nil
+	default:
+		return nil, ErrPointerLeaf
+	}
+}
+
+// sliceIndex parses a reference token as an index into a slice of
+// length l. end allows the index l, written as a number or "-".
+func sliceIndex(token string, l int, end bool) (int, error) {
+	if token == "-" {
+		if end {
+			return l, nil
+		}
+		return 0, ErrPointerIndex
+	}
+	if token == "" || (token[0] == '0' && len(token) > 1) {
+		return 0, ErrPointerIndex
+	}
+	for _, c := range token {
+		if c < '0' || c > '9' {
+			return 0, ErrPointerIndex
+		}
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i > l || (i == l && !end) {
+		return 0, ErrPointerIndex
+	}
+	return i, nil
+}
+
+// parsePointer splits a JSON pointer into its unescaped reference
+// tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if pointer[0] != '/' {
+		return nil, &PointerError{Pointer: pointer, Prefix: pointer, Err: ErrPointerSyntax}
+	}
+	raw := strings.Split(pointer[1:], "/")
+	tokens := make([]string, len(raw))
+	unescape := strings.NewReplacer("~1", "/", "~0", "~")
+	for n, token := range raw {
+		for i := 0; i < len(token); i++ {
+			if token[i] == '~' && (i+1 == len(token) || (token[i+1] != '0' && token[i+1] != '1')) {
+				prefix := "/" + strings.Join(raw[:n+1], "/")
+				return nil, &PointerError{Pointer: pointer, Prefix: prefix, Err: ErrPointerSyntax}
+			}
+		}
+		tokens[n] = unescape.Replace(token)
+	}
+	return tokens, nil
+}
+
+// formatPointer builds a JSON pointer from unescaped reference tokens.
+func formatPointer(tokens []string) string {
+	var sb strings.Builder
+	escape := strings.NewReplacer("~", "~0", "/", "~1")
+	for _, token := range tokens {
+		sb.WriteByte('/')
+		sb.WriteString(escape.Replace(token))
+	}
+	return sb.String()
+}
+
+func pointerError(pointer string, tokens []string, err error) error {
+	return &PointerError{Pointer: pointer, Prefix: formatPointer(tokens), Err: err}
+}
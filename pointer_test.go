@@ -0,0 +1,109 @@
+package amorph_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/clucia/amorph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPointerGet(t *testing.T) {
+	data, err := amorph.NewAmorphFromFile("test.json")
+	assert.Nil(t, err)
+
+	v, err := amorph.Get(data, "/0/config/webaddresses/1")
+	assert.Nil(t, err)
+	assert.Equal(t, "http://example0.mydomain.com", v)
+	v, err = amorph.Get(data, "")
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(v, data))
+	assert.True(t, amorph.Exists(data, "/0/slug"))
+	assert.False(t, amorph.Exists(data, "/0/nosuchkey"))
+
+	// RFC 6901 escaping
+	doc := map[string]interface{}{"a/b": 1.0, "m~n": 2.0, "": 3.0}
+	v, err = amorph.Get(doc, "/a~1b")
+	assert.Nil(t, err)
+	assert.Equal(t, 1.0, v)
+	v, err = amorph.Get(doc, "/m~0n")
+	assert.Nil(t, err)
+	assert.Equal(t, 2.0, v)
+	v, err = amorph.Get(doc, "/")
+	assert.Nil(t, err)
+	assert.Equal(t, 3.0, v)
+}
+
+func TestPointerErrors(t *testing.T) {
+	doc := map[string]interface{}{"list": []interface{}{"a", "b"}, "leaf": "x"}
+	for pointer, sentinel := range map[string]error{
+		"nope":        amorph.ErrPointerSyntax,
+		"/bad~2":      amorph.ErrPointerSyntax,
+		"/missing":    amorph.ErrPointerNotFound,
+		"/list/2":     amorph.ErrPointerIndex,
+		"/list/-":     amorph.ErrPointerIndex,
+		"/list/01":    amorph.ErrPointerIndex,
+		"/list/x":     amorph.ErrPointerIndex,
+		"/leaf/x":     amorph.ErrPointerLeaf,
+		"/missing/xx": amorph.ErrPointerNotFound,
+	} {
+		_, err := amorph.Get(doc, pointer)
+		assert.True(t, errors.Is(err, sentinel), pointer)
+		var pe *amorph.PointerError
+		assert.True(t, errors.As(err, &pe), pointer)
+		assert.Equal(t, pointer, pe.Pointer)
+	}
+	_, err := amorph.Get(doc, "/missing/xx")
+	assert.Equal(t, `"/missing": JSON pointer key not found`, err.Error())
+}
+
+func TestPointerSetAddDelete(t *testing.T) {
+	doc, err := amorph.NewAmorphFromString(`{"list": ["a", "b"], "m": {"k": 1}}`)
+	assert.Nil(t, err)
+
+	doc, err = amorph.Set(doc, "/m/k", 2.0)
+	assert.Nil(t, err)
+	doc, err = amorph.Set(doc, "/list/0", "A")
+	assert.Nil(t, err)
+	doc, err = amorph.Set(doc, "/list/-", "c")
+	assert.Nil(t, err)
+	doc, err = amorph.Add(doc, "/list/1", "ins")
+	assert.Nil(t, err)
+	expected, _ := amorph.NewAmorphFromString(`{"list": ["A", "ins", "b", "c"], "m": {"k": 2}}`)
+	assert.True(t, amorph.DeepEqual(doc, expected))
+
+	_, err = amorph.Set(doc, "/list/9", "x")
+	assert.True(t, errors.Is(err, amorph.ErrPointerIndex))
+	appended, err := amorph.Set(amorph.DeepCopy(doc), "/list/4", "d")
+	assert.Nil(t, err)
+	assert.Equal(t, "d", appended.(map[string]interface{})["list"].([]interface{})[4])
+	_, err = amorph.Set(doc, "/new/deep/key", "x")
+	assert.True(t, errors.Is(err, amorph.ErrPointerNotFound))
+
+	doc, err = amorph.Set(doc, "/new/deep/key", "x", amorph.OptPointerCreate)
+	assert.Nil(t, err)
+	doc, err = amorph.Add(doc, "/new/items/-", "y", amorph.OptPointerCreate)
+	assert.Nil(t, err)
+	v, err := amorph.Get(doc, "/new")
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(v, map[string]interface{}{
+		"deep":  map[string]interface{}{"key": "x"},
+		"items": []interface{}{"y"},
+	}))
+
+	doc, err = amorph.Delete(doc, "/list/1")
+	assert.Nil(t, err)
+	doc, err = amorph.Delete(doc, "/new")
+	assert.Nil(t, err)
+	expected, _ = amorph.NewAmorphFromString(`{"list": ["A", "b", "c"], "m": {"k": 2}}`)
+	assert.True(t, amorph.DeepEqual(doc, expected))
+	_, err = amorph.Delete(doc, "/new")
+	assert.True(t, errors.Is(err, amorph.ErrPointerNotFound))
+
+	root, err := amorph.Set(doc, "", "replaced")
+	assert.Nil(t, err)
+	assert.Equal(t, "replaced", root)
+	root, err = amorph.Delete(doc, "")
+	assert.Nil(t, err)
+	assert.Equal(t, amorph.NULL, root)
+}
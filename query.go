@@ -0,0 +1,493 @@
+package amorph
+
+// Copyright 2021 Charles J. Luciano and Scalability
+// Labs LLC. All rights reserved. Use of this source
+// code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// A QueryMatch is a node selected by Query: its value and its Path.
+type QueryMatch struct {
+	Path  Path
+	Value Amorph
+}
+
+// Pointer returns the JSON pointer to the node, for use with Get, Set,
+// Add and Delete.
+func (qm QueryMatch) Pointer() string {
+	return qm.Path.JSONPointer()
+}
+
+// NormalizedPath returns the RFC 9535 normalized path to the node, such
+// as $['config']['webaddresses'][1].
+func (qm QueryMatch) NormalizedPath() string {
+	var sb strings.Builder
+	sb.WriteByte('$')
+	for _, key := range qm.Path {
+		switch ckey := key.(type) {
+		case int:
+			sb.WriteString("[" + strconv.Itoa(ckey) + "]")
+		case string:
+			sb.WriteString("['")
+			for _, r := range ckey {
+				switch r {
+				case '\\':
+					sb.WriteString(`\\`)
+				case '\'':
+					sb.WriteString(`\'`)
+				case '\b':
+					sb.WriteString(`\b`)
+				case '\f':
+					sb.WriteString(`\f`)
+				case '\n':
+					sb.WriteString(`\n`)
+				case '\r':
+					sb.WriteString(`\r`)
+				case '\t':
+					sb.WriteString(`\t`)
+				default:
+					if r < 0x20 {
+						fmt.Fprintf(&sb, `\u%04x`, r)
+					} else {
+						sb.WriteRune(r)
+					}
+				}
+			}
+			sb.WriteString("']")
+		}
+	}
+	return sb.String()
+}
+
+// Query evaluates an RFC 9535 JSONPath query, such as
+// "$[?@.config.addr=='10.0.22.98'].slug", and returns the nodes it
+// selects, in order.
+//
+// The whole of RFC 9535 is supported: name, wildcard, index, slice and
+// filter selectors, child and descendant segments, and the length,
+// count, match, search and value functions. As a convenience, a "." may
+// also be followed by a bracketed selection, as in "$.[0]". The members
+// of a map are visited in sorted key order, and NULL holes in slices are
+// skipped.
+//
+// A query that isn't valid gets a *QueryError wrapping ErrQuerySyntax or
+// ErrQueryFunction.
+func Query(a Amorph, query string) ([]QueryMatch, error) {
+	q, err := parseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	nodes := q.eval(a, jpNode{path: []interface{}{}, value: a})
+	matches := make([]QueryMatch, len(nodes))
+	for i, n := range nodes {
+		matches[i] = QueryMatch{Path: n.path, Value: n.value}
+	}
+	return matches, nil
+}
+
+// A QueryError reports where a JSONPath query went wrong.
+type QueryError struct {
+	Query  string
+	Offset int // the byte offset in Query
+	Err    error
+}
+
+func (qe *QueryError) Error() string {
+	return strconv.Quote(qe.Query) + " at offset " + strconv.Itoa(qe.Offset) + ": " + qe.Err.Error()
+}
+
+func (qe *QueryError) Unwrap() error {
+	return qe.Err
+}
+
+// jpNode is a node of the Amorph being queried.
+type jpNode struct {
+	path  []interface{}
+	value Amorph
+}
+
+// jpQuery is a parsed query: a list of segments applied to the root, or
+// to the current node of a filter (relative).
+type jpQuery struct {
+	relative bool
+	segments []jpSegment
+}
+
+type jpSegment struct {
+	descendant bool
+	selectors  []jpSelector
+}
+
+func (q *jpQuery) eval(root Amorph, cur jpNode) []jpNode {
+	nodes := []jpNode{cur}
+	if !q.relative {
+		nodes = []jpNode{{path: []interface{}{}, value: root}}
+	}
+	for _, seg := range q.segments {
+		out := make([]jpNode, 0)
+		for _, n := range nodes {
+			targets := []jpNode{n}
+			if seg.descendant {
+				targets = jpDescendants(n, targets[:0])
+			}
+			for _, t := range targets {
+				for _, sel := range seg.selectors {
+					out = sel.apply(root, t, out)
+				}
+			}
+		}
+		nodes = out
+	}
+	return nodes
+}
+
+// singular reports whether the query selects at most one node.
+func (q *jpQuery) singular() bool {
+	for _, seg := range q.segments {
+		if seg.descendant || len(seg.selectors) != 1 {
+			return false
+		}
+		switch seg.selectors[0].(type) {
+		case jpName, jpIndex:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// jpChildren gets the members of a map, in sorted key order, or the
+// elements of a slice.
+func jpChildren(n jpNode) []jpNode {
+	children := make([]jpNode, 0)
+	switch cv := n.value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(cv))
+		for k := range cv {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if cv[k] != NULL {
+				children = append(children, jpNode{path: appendPath(n.path, k), value: cv[k]})
+			}
+		}
+	case []interface{}:
+		for i, v := range cv {
+			if v != NULL {
+				children = append(children, jpNode{path: appendPath(n.path, i), value: v})
+			}
+		}
+	}
+	return children
+}
+
+// jpDescendants appends n and its descendants to out, each node before
+// its children.
+func jpDescendants(n jpNode, out []jpNode) []jpNode {
+	out = append(out, n)
+	for _, child := range jpChildren(n) {
+		out = jpDescendants(child, out)
+	}
+	return out
+}
+
+type jpSelector interface {
+	apply(root Amorph, n jpNode, out []jpNode) []jpNode
+}
+
+type jpName string
+type jpWildcard struct{}
+type jpIndex int
+
+type jpSlice struct {
+	start, end *int
+	step       int
+}
+
+type jpFilter struct {
+	expr jpLogical
+}
+
+func (sel jpName) apply(root Amorph, n jpNode, out []jpNode) []jpNode {
+	if m, ok := n.value.(map[string]interface{}); ok {
+		if v, ok := m[string(sel)]; ok && v != NULL {
+			out = append(out, jpNode{path: appendPath(n.path, string(sel)), value: v})
+		}
+	}
+	return out
+}
+
+func (sel jpWildcard) apply(root Amorph, n jpNode, out []jpNode) []jpNode {
+	return append(out, jpChildren(n)...)
+}
+
+func (sel jpIndex) apply(root Amorph, n jpNode, out []jpNode) []jpNode {
+	s, ok := n.value.([]interface{})
+	if !ok {
+		return out
+	}
+	i := int(sel)
+	if i < 0 {
+		i += len(s)
+	}
+	if i >= 0 && i < len(s) && s[i] != NULL {
+		out = append(out, jpNode{path: appendPath(n.path, i), value: s[i]})
+	}
+	return out
+}
+
+func (sel jpSlice) apply(root Amorph, n jpNode, out []jpNode) []jpNode {
+	s, ok := n.value.([]interface{})
+	if !ok || sel.step == 0 {
+		return out
+	}
+	l := len(s)
+	normalize := func(i *int, def int) int {
+		if i == nil {
+			return def
+		}
+		if *i < 0 {
+			return *i + l
+		}
+		return *i
+	}
+	clamp := func(i, lo, hi int) int {
+		if i < lo {
+			return lo
+		}
+		if i > hi {
+			return hi
+		}
+		return i
+	}
+	add := func(i int) {
+		if s[i] != NULL {
+			out = append(out, jpNode{path: appendPath(n.path, i), value: s[i]})
+		}
+	}
+	if sel.step > 0 {
+		lower := clamp(normalize(sel.start, 0), 0, l)
+		upper := clamp(normalize(sel.end, l), 0, l)
+		for i := lower; i < upper; i += sel.step {
+			add(i)
+		}
+	} else {
+		upper := clamp(normalize(sel.start, l-1), -1, l-1)
+		lower := clamp(normalize(sel.end, -l-1), -1, l-1)
+		for i := upper; i > lower; i += sel.step {
+			add(i)
+		}
+	}
+	return out
+}
+
+func (sel jpFilter) apply(root Amorph, n jpNode, out []jpNode) []jpNode {
+	for _, child := range jpChildren(n) {
+		if sel.expr.test(root, child) {
+			out = append(out, child)
+		}
+	}
+	return out
+}
+
+// jpLogical is a filter expression.
+type jpLogical interface {
+	test(root Amorph, cur jpNode) bool
+}
+
+type jpOr []jpLogical
+type jpAnd []jpLogical
+
+type jpNot struct {
+	expr jpLogical
+}
+
+// jpExists tests that a query selects at least one node.
+type jpExists struct {
+	q *jpQuery
+}
+
+type jpCompare struct {
+	left, right jpComparable
+	op          string
+}
+
+func (expr jpOr) test(root Amorph, cur jpNode) bool {
+	for _, e := range expr {
+		if e.test(root, cur) {
+			return true
+		}
+	}
+	return false
+}
+
+func (expr jpAnd) test(root Amorph, cur jpNode) bool {
+	for _, e := range expr {
+		if !e.test(root, cur) {
+			return false
+		}
+	}
+	return true
+}
+
+func (expr jpNot) test(root Amorph, cur jpNode) bool {
+	return !expr.expr.test(root, cur)
+}
+
+func (expr jpExists) test(root Amorph, cur jpNode) bool {
+	return len(expr.q.eval(root, cur)) > 0
+}
+
+func (expr jpCompare) test(root Amorph, cur jpNode) bool {
+	v0, ok0 := expr.left.value(root, cur)
+	v1, ok1 := expr.right.value(root, cur)
+	switch expr.op {
+	case "==":
+		return jpEqual(v0, ok0, v1, ok1)
+	case "!=":
+		return !jpEqual(v0, ok0, v1, ok1)
+	case "<":
+		return jpLess(v0, ok0, v1, ok1)
+	case "<=":
+		return jpLess(v0, ok0, v1, ok1) || jpEqual(v0, ok0, v1, ok1)
+	case ">":
+		return jpLess(v1, ok1, v0, ok0)
+	default: // ">="
+		return jpLess(v1, ok1, v0, ok0) || jpEqual(v0, ok0, v1, ok1)
+	}
+}
+
+// jpEqual compares two values; ok is false for Nothing, the value of a
+// query that selects no node.
+func jpEqual(v0 Amorph, ok0 bool, v1 Amorph, ok1 bool) bool {
+	if !ok0 || !ok1 {
+		return !ok0 && !ok1
+	}
+	return DeepEqual(v0, v1)
+}
+
+// jpLess orders numbers and strings; any other values are unordered.
+func jpLess(v0 Amorph, ok0 bool, v1 Amorph, ok1 bool) bool {
+	if !ok0 || !ok1 {
+		return false
+	}
+	switch cv0 := v0.(type) {
+	case float64:
+		cv1, ok := v1.(float64)
+		return ok && cv0 < cv1
+	case string:
+		cv1, ok := v1.(string)
+		return ok && cv0 < cv1
+	}
+	return false
+}
+
+// jpComparable is an operand of a comparison. ok is false for Nothing.
+type jpComparable interface {
+	value(root Amorph, cur jpNode) (v Amorph, ok bool)
+}
+
+type jpLiteral struct {
+	v Amorph
+}
+
+// jpSingular is a singular query used as a value.
+type jpSingular struct {
+	q *jpQuery
+}
+
+func (lit jpLiteral) value(root Amorph, cur jpNode) (Amorph, bool) {
+	return lit.v, true
+}
+
+func (sq jpSingular) value(root Amorph, cur jpNode) (Amorph, bool) {
+	nodes := sq.q.eval(root, cur)
+	if len(nodes) != 1 {
+		return nil, false
+	}
+	return nodes[0].value, true
+}
+
+// The types of the function parameters and results.
+const (
+	jpValueType = iota
+	jpLogicalType
+	jpNodesType
+)
+
+var jpFunctions = map[string]struct {
+	params []int
+	result int
+}{
+	"length": {[]int{jpValueType}, jpValueType},
+	"count":  {[]int{jpNodesType}, jpValueType},
+	"match":  {[]int{jpValueType, jpValueType}, jpLogicalType},
+	"search": {[]int{jpValueType, jpValueType}, jpLogicalType},
+	"value":  {[]int{jpNodesType}, jpValueType},
+}
+
+// jpFunc is a function call. Each argument is either a query, for a
+// NodesType parameter, or a value.
+type jpFunc struct {
+	name string
+	args []jpArg
+}
+
+type jpArg struct {
+	q   *jpQuery
+	val jpComparable
+}
+
+func (fn *jpFunc) value(root Amorph, cur jpNode) (Amorph, bool) {
+	switch fn.name {
+	case "length":
+		v, ok := fn.args[0].val.value(root, cur)
+		if !ok {
+			return nil, false
+		}
+		switch cv := v.(type) {
+		case string:
+			return float64(utf8.RuneCountInString(cv)), true
+		case []interface{}:
+			return float64(len(cv)), true
+		case map[string]interface{}:
+			return float64(len(cv)), true
+		}
+		return nil, false
+	case "count":
+		return float64(len(fn.args[0].q.eval(root, cur))), true
+	default: // "value"
+		nodes := fn.args[0].q.eval(root, cur)
+		if len(nodes) != 1 {
+			return nil, false
+		}
+		return nodes[0].value, true
+	}
+}
+
+func (fn *jpFunc) test(root Amorph, cur jpNode) bool {
+	v0, ok0 := fn.args[0].val.value(root, cur)
+	v1, ok1 := fn.args[1].val.value(root, cur)
+	s, isString := v0.(string)
+	pattern, isPattern := v1.(string)
+	if !ok0 || !ok1 || !isString || !isPattern {
+		return false
+	}
+	if fn.name == "match" {
+		pattern = "^(?:" + pattern + ")$"
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return false
+	}
+	return re.MatchString(s)
+}
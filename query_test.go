@@ -0,0 +1,136 @@
+package amorph_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/clucia/amorph"
+	"github.com/stretchr/testify/assert"
+)
+
+// queryValues runs a query and returns the values and normalized paths.
+func queryValues(t *testing.T, a amorph.Amorph, query string) ([]interface{}, []string) {
+	matches, err := amorph.Query(a, query)
+	assert.Nil(t, err, query)
+	values := make([]interface{}, 0, len(matches))
+	paths := make([]string, 0, len(matches))
+	for _, m := range matches {
+		values = append(values, m.Value)
+		paths = append(paths, m.NormalizedPath())
+	}
+	return values, paths
+}
+
+func TestQuery(t *testing.T) {
+	data, err := amorph.NewAmorphFromFile("test.json")
+	assert.Nil(t, err)
+
+	values, paths := queryValues(t, data, "$.[?@.config.addr=='10.0.22.98'].slug")
+	assert.Equal(t, []interface{}{"exam1"}, values)
+	assert.Equal(t, []string{"$[1]['slug']"}, paths)
+
+	values, _ = queryValues(t, data, "$[*].slug")
+	assert.Equal(t, []interface{}{"exam0", "exam1"}, values)
+	values, _ = queryValues(t, data, "$..webaddresses[1]")
+	assert.Equal(t, []interface{}{"http://example0.mydomain.com", "http://example1.mydomain.com"}, values)
+	values, _ = queryValues(t, data, "$[1].config.webaddresses[::-1]")
+	assert.Equal(t, []interface{}{"http://mydomain.com", "http://example1.mydomain.com", "http://www.mydomain.com"}, values)
+	values, _ = queryValues(t, data, "$[1].config.webaddresses[1:]")
+	assert.Equal(t, []interface{}{"http://example1.mydomain.com", "http://mydomain.com"}, values)
+	values, _ = queryValues(t, data, `$[?length(@.name) > 13]["slug", 'name']`)
+	assert.Equal(t, []interface{}{"exam1", "another example thing"}, values)
+	values, _ = queryValues(t, data, "$[?count(@.config.webaddresses[*]) == 3 && search(@.slug, '0$')].slug")
+	assert.Equal(t, []interface{}{"exam0"}, values)
+	values, _ = queryValues(t, data, "$[?!(@.slug == 'exam0') || match(@.slug, 'ex')].slug")
+	assert.Equal(t, []interface{}{"exam1"}, values)
+	values, _ = queryValues(t, data, "$[?@.nosuchkey].slug")
+	assert.Equal(t, []interface{}{}, values)
+	values, _ = queryValues(t, data, "$[?!@.nosuchkey && @.config.addr < '10.0.3'].slug")
+	assert.Equal(t, []interface{}{"exam1"}, values)
+	values, _ = queryValues(t, data, "$[?value(@..addr) == $[0].config.addr].slug")
+	assert.Equal(t, []interface{}{"exam0"}, values)
+}
+
+func TestQueryRFC(t *testing.T) {
+	doc, err := amorph.NewAmorphFromString(`{
+		"o": {"j j": {"k.k": 3}, "a'b": 1},
+		"a": [3, 5, 1, 2, 4, 6, {"b": "j"}, {"b": "k"}, {"b": {}}, {"b": "kilo"}],
+		"n": [null, true, false, 0, 1.5e1]
+	}`)
+	assert.Nil(t, err)
+
+	values, _ := queryValues(t, doc, `$.o['j j']['k.k']`)
+	assert.Equal(t, []interface{}{3.0}, values)
+	_, paths := queryValues(t, doc, `$.o[*]`)
+	assert.Equal(t, []string{`$['o']['a\'b']`, `$['o']['j j']`}, paths)
+	values, _ = queryValues(t, doc, `$.a[1:6:2]`)
+	assert.Equal(t, []interface{}{5.0, 2.0, 6.0}, values)
+	values, _ = queryValues(t, doc, `$.a[5:1:-2]`)
+	assert.Equal(t, []interface{}{6.0, 2.0}, values)
+	values, _ = queryValues(t, doc, `$.a[0, 0, -11, 99]`)
+	assert.Equal(t, []interface{}{3.0, 3.0}, values)
+	values, _ = queryValues(t, doc, `$.a[?@ > 3.5]`)
+	assert.Equal(t, []interface{}{5.0, 4.0, 6.0}, values)
+	values, _ = queryValues(t, doc, `$.a[?@.b == 'k' || @.b == 'x']`)
+	assert.Equal(t, []interface{}{map[string]interface{}{"b": "k"}}, values)
+	values, _ = queryValues(t, doc, `$.a[?match(@.b, "k.*")].b`)
+	assert.Equal(t, []interface{}{"k", "kilo"}, values)
+	values, _ = queryValues(t, doc, `$.n[?@ == null || @ == true || @ == 15]`)
+	assert.Equal(t, []interface{}{nil, true, 15.0}, values)
+	values, _ = queryValues(t, doc, `$..k.k`)
+	assert.Equal(t, []interface{}{}, values)
+	values, _ = queryValues(t, doc, `$..['k.k']`)
+	assert.Equal(t, []interface{}{3.0}, values)
+	values, _ = queryValues(t, doc, `$`)
+	assert.Len(t, values, 1)
+}
+
+func TestQueryErrors(t *testing.T) {
+	for query, sentinel := range map[string]error{
+		``:                         amorph.ErrQuerySyntax,
+		`a`:                        amorph.ErrQuerySyntax,
+		`$.`:                       amorph.ErrQuerySyntax,
+		`$[01]`:                    amorph.ErrQuerySyntax,
+		`$.a[9007199254740992]`:    amorph.ErrQuerySyntax,
+		`$.a[-9007199254740992]`:   amorph.ErrQuerySyntax,
+		`$.a[1:9007199254740992]`:  amorph.ErrQuerySyntax,
+		`$['unterminated`:          amorph.ErrQuerySyntax,
+		`$[?@.a == 1`:              amorph.ErrQuerySyntax,
+		`$[?!@.a == 1]`:            amorph.ErrQuerySyntax,
+		`$[?@..a == 1]`:            amorph.ErrQueryFunction,
+		`$[?length(@.*) == 1]`:     amorph.ErrQueryFunction,
+		`$[?count(1) == 1]`:        amorph.ErrQueryFunction,
+		`$[?nosuch(@) == 1]`:       amorph.ErrQueryFunction,
+		`$[?length(@)]`:            amorph.ErrQueryFunction,
+		`$[?match(@.a, 'a') == 1]`: amorph.ErrQueryFunction,
+	} {
+		_, err := amorph.Query(nil, query)
+		assert.True(t, errors.Is(err, sentinel), query)
+		var qe *amorph.QueryError
+		assert.True(t, errors.As(err, &qe), query)
+	}
+}
+
+func TestQueryIndexRange(t *testing.T) {
+	// the largest index RFC 9535 allows is still an index
+	matches, err := amorph.Query([]interface{}{"a"}, "$[9007199254740991]")
+	assert.Nil(t, err)
+	assert.Len(t, matches, 0)
+	matches, err = amorph.Query([]interface{}{"a"}, "$[-9007199254740991:]")
+	assert.Nil(t, err)
+	assert.Len(t, matches, 1)
+}
+
+func TestQueryPointer(t *testing.T) {
+	data, err := amorph.NewAmorphFromFile("test.json")
+	assert.Nil(t, err)
+	matches, err := amorph.Query(data, "$..rootpassword")
+	assert.Nil(t, err)
+	assert.Equal(t, "/0/config/rootpassword", matches[0].Pointer())
+	for _, m := range matches {
+		data, err = amorph.Set(data, m.Pointer(), "redacted")
+		assert.Nil(t, err)
+	}
+	values, _ := queryValues(t, data, "$..rootpassword")
+	assert.Equal(t, []interface{}{"redacted", "redacted"}, values)
+}
@@ -0,0 +1,541 @@
+package amorph
+
+// Copyright 2021 Charles J. Luciano and Scalability
+// Labs LLC. All rights reserved. Use of this source
+// code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// queryParser parses a JSONPath query by recursive descent.
+type queryParser struct {
+	query string
+	pos   int
+}
+
+func parseQuery(query string) (*jpQuery, error) {
+	p := &queryParser{query: query}
+	if !p.eat("$") {
+		return nil, p.fail(ErrQuerySyntax)
+	}
+	q, err := p.segments(false)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.query) {
+		return nil, p.fail(ErrQuerySyntax)
+	}
+	return q, nil
+}
+
+func (p *queryParser) fail(err error) error {
+	return &QueryError{Query: p.query, Offset: p.pos, Err: err}
+}
+
+// eat skips s if it comes next.
+func (p *queryParser) eat(s string) bool {
+	if strings.HasPrefix(p.query[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (p *queryParser) peek() byte {
+	if p.pos < len(p.query) {
+		return p.query[p.pos]
+	}
+	return 0
+}
+
+// blank skips blank space.
+func (p *queryParser) blank() {
+	for p.pos < len(p.query) && strings.IndexByte(" \t\n\r", p.query[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// segments parses the segments after "$" or "@".
+func (p *queryParser) segments(relative bool) (*jpQuery, error) {
+	q := &jpQuery{relative: relative}
+	for {
+		save := p.pos
+		p.blank()
+		var seg jpSegment
+		var err error
+		switch {
+		case p.eat(".."):
+			seg.descendant = true
+			seg.selectors, err = p.dotted()
+		case p.eat("."):
+			seg.selectors, err = p.dotted()
+		case p.peek() == '[':
+			seg.selectors, err = p.bracketed()
+		default:
+			p.pos = save
+			return q, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		q.segments = append(q.segments, seg)
+	}
+}
+
+// dotted parses what follows a "." or "..": a name, "*" or a bracketed
+// selection.
+func (p *queryParser) dotted() ([]jpSelector, error) {
+	switch {
+	case p.eat("*"):
+		return []jpSelector{jpWildcard{}}, nil
+	case p.peek() == '[':
+		return p.bracketed()
+	}
+	start := p.pos
+	for p.pos < len(p.query) {
+		r, size := utf8.DecodeRuneInString(p.query[p.pos:])
+		nameFirst := r == '_' || r >= 0x80 || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		if !nameFirst && (p.pos == start || r < '0' || r > '9') {
+			break
+		}
+		p.pos += size
+	}
+	if p.pos == start {
+		return nil, p.fail(ErrQuerySyntax)
+	}
+	return []jpSelector{jpName(p.query[start:p.pos])}, nil
+}
+
+// bracketed parses a list of selectors in brackets.
+func (p *queryParser) bracketed() ([]jpSelector, error) {
+	p.eat("[")
+	selectors := make([]jpSelector, 0, 1)
+	for {
+		p.blank()
+		sel, err := p.selector()
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, sel)
+		p.blank()
+		if p.eat("]") {
+			return selectors, nil
+		}
+		if !p.eat(",") {
+			return nil, p.fail(ErrQuerySyntax)
+		}
+	}
+}
+
+func (p *queryParser) selector() (jpSelector, error) {
+	switch c := p.peek(); {
+	case c == '\'' || c == '"':
+		name, err := p.stringLiteral()
+		if err != nil {
+			return nil, err
+		}
+		return jpName(name), nil
+	case p.eat("*"):
+		return jpWildcard{}, nil
+	case p.eat("?"):
+		p.blank()
+		expr, err := p.logicalOr()
+		if err != nil {
+			return nil, err
+		}
+		return jpFilter{expr}, nil
+	}
+	start, hasStart, err := p.index()
+	if err != nil {
+		return nil, err
+	}
+	p.blank()
+	if !p.eat(":") {
+		if !hasStart {
+			return nil, p.fail(ErrQuerySyntax)
+		}
+		return jpIndex(start), nil
+	}
+	sel := jpSlice{step: 1}
+	if hasStart {
+		sel.start = &start
+	}
+	p.blank()
+	end, hasEnd, err := p.index()
+	if err != nil {
+		return nil, err
+	}
+	if hasEnd {
+		sel.end = &end
+	}
+	p.blank()
+	if p.eat(":") {
+		p.blank()
+		step, hasStep, err := p.index()
+		if err != nil {
+			return nil, err
+		}
+		if hasStep {
+			sel.step = step
+		}
+	}
+	return sel, nil
+}
+
+// jpMaxIndex is the largest index or slice bound RFC 9535 allows, the
+// largest integer that I-JSON numbers hold exactly.
+const jpMaxIndex = 1<<53 - 1
+
+// index parses an index or slice bound, if one comes next. One outside
+// the I-JSON range is a syntax error.
+func (p *queryParser) index() (int, bool, error) {
+	start := p.pos
+	i, ok := p.integer()
+	if ok && (i > jpMaxIndex || i < -jpMaxIndex) {
+		p.pos = start
+		return 0, false, p.fail(ErrQuerySyntax)
+	}
+	return i, ok, nil
+}
+
+// integer parses an integer without leading zeros, if one comes next.
+func (p *queryParser) integer() (int, bool) {
+	start := p.pos
+	p.eat("-")
+	digits := p.pos
+	for p.pos < len(p.query) && p.query[p.pos] >= '0' && p.query[p.pos] <= '9' {
+		p.pos++
+	}
+	text := p.query[start:p.pos]
+	if p.pos == digits || (p.query[digits] == '0' && (p.pos > digits+1 || text == "-0")) {
+		p.pos = start
+		return 0, false
+	}
+	i, err := strconv.Atoi(text)
+	if err != nil {
+		p.pos = start
+		return 0, false
+	}
+	return i, true
+}
+
+// stringLiteral parses a string in single or double quotes.
+func (p *queryParser) stringLiteral() (string, error) {
+	quote := p.query[p.pos]
+	p.pos++
+	var sb strings.Builder
+	for p.pos < len(p.query) {
+		c := p.query[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			return sb.String(), nil
+		case c < 0x20:
+			return "", p.fail(ErrQuerySyntax)
+		case c != '\\':
+			sb.WriteByte(c)
+			p.pos++
+			continue
+		}
+		p.pos++
+		switch e := p.peek(); e {
+		case 'b':
+			sb.WriteByte('\b')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 't':
+			sb.WriteByte('\t')
+		case '/', '\\', quote:
+			sb.WriteByte(e)
+		case 'u':
+			p.pos++
+			r, ok := p.hex4()
+			if ok && utf16.IsSurrogate(r) {
+				var r2 rune
+				if ok = p.eat(`\u`); ok {
+					r2, ok = p.hex4()
+					r = utf16.DecodeRune(r, r2)
+					ok = ok && r != utf8.RuneError
+				}
+			}
+			if !ok {
+				return "", p.fail(ErrQuerySyntax)
+			}
+			sb.WriteRune(r)
+			continue
+		default:
+			return "", p.fail(ErrQuerySyntax)
+		}
+		p.pos++
+	}
+	return "", p.fail(ErrQuerySyntax)
+}
+
+func (p *queryParser) hex4() (rune, bool) {
+	if p.pos+4 > len(p.query) {
+		return 0, false
+	}
+	v, err := strconv.ParseUint(p.query[p.pos:p.pos+4], 16, 32)
+	if err != nil {
+		return 0, false
+	}
+	p.pos += 4
+	return rune(v), true
+}
+
+func (p *queryParser) logicalOr() (jpLogical, error) {
+	expr, err := p.logicalAnd()
+	if err != nil {
+		return nil, err
+	}
+	or := jpOr{expr}
+	for {
+		p.blank()
+		if !p.eat("||") {
+			break
+		}
+		p.blank()
+		expr, err := p.logicalAnd()
+		if err != nil {
+			return nil, err
+		}
+		or = append(or, expr)
+	}
+	if len(or) == 1 {
+		return or[0], nil
+	}
+	return or, nil
+}
+
+func (p *queryParser) logicalAnd() (jpLogical, error) {
+	expr, err := p.basic()
+	if err != nil {
+		return nil, err
+	}
+	and := jpAnd{expr}
+	for {
+		p.blank()
+		if !p.eat("&&") {
+			break
+		}
+		p.blank()
+		expr, err := p.basic()
+		if err != nil {
+			return nil, err
+		}
+		and = append(and, expr)
+	}
+	if len(and) == 1 {
+		return and[0], nil
+	}
+	return and, nil
+}
+
+// basic parses a parenthesized expression, a negation, a comparison or
+// a test.
+func (p *queryParser) basic() (jpLogical, error) {
+	if p.eat("!") {
+		p.blank()
+		if p.peek() == '(' {
+			expr, err := p.paren()
+			if err != nil {
+				return nil, err
+			}
+			return jpNot{expr}, nil
+		}
+		expr, err := p.basic()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := expr.(jpCompare); ok {
+			// "!" applies to a test or parenthesized expression only
+			return nil, p.fail(ErrQuerySyntax)
+		}
+		return jpNot{expr}, nil
+	}
+	if p.peek() == '(' {
+		return p.paren()
+	}
+	left, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	p.blank()
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if !p.eat(op) {
+			continue
+		}
+		p.blank()
+		right, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		c0, ok0 := left.comparable()
+		c1, ok1 := right.comparable()
+		if !ok0 || !ok1 {
+			return nil, p.fail(ErrQueryFunction)
+		}
+		return jpCompare{left: c0, right: c1, op: op}, nil
+	}
+	switch {
+	case left.q != nil:
+		return jpExists{left.q}, nil
+	case left.fn != nil && jpFunctions[left.fn.name].result == jpLogicalType:
+		return left.fn, nil
+	}
+	return nil, p.fail(ErrQueryFunction)
+}
+
+// paren parses an expression in parentheses.
+func (p *queryParser) paren() (jpLogical, error) {
+	p.eat("(")
+	p.blank()
+	expr, err := p.logicalOr()
+	if err != nil {
+		return nil, err
+	}
+	p.blank()
+	if !p.eat(")") {
+		return nil, p.fail(ErrQuerySyntax)
+	}
+	return expr, nil
+}
+
+// jpOperand is a literal, a query or a function call in a filter.
+type jpOperand struct {
+	lit *jpLiteral
+	q   *jpQuery
+	fn  *jpFunc
+}
+
+// comparable gets the operand as a value, if it is one: a literal, a
+// singular query or a function with a ValueType result.
+func (o jpOperand) comparable() (jpComparable, bool) {
+	switch {
+	case o.lit != nil:
+		return *o.lit, true
+	case o.q != nil && o.q.singular():
+		return jpSingular{o.q}, true
+	case o.fn != nil && jpFunctions[o.fn.name].result == jpValueType:
+		return o.fn, true
+	}
+	return nil, false
+}
+
+func (p *queryParser) operand() (jpOperand, error) {
+	switch c := p.peek(); {
+	case c == '@' || c == '$':
+		p.pos++
+		q, err := p.segments(c == '@')
+		return jpOperand{q: q}, err
+	case c == '\'' || c == '"':
+		s, err := p.stringLiteral()
+		return jpOperand{lit: &jpLiteral{s}}, err
+	case c == '-' || (c >= '0' && c <= '9'):
+		return p.number()
+	case c >= 'a' && c <= 'z':
+		start := p.pos
+		for c := p.peek(); (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '_'; c = p.peek() {
+			p.pos++
+		}
+		name := p.query[start:p.pos]
+		if p.peek() == '(' {
+			return p.function(name)
+		}
+		switch name {
+		case "true":
+			return jpOperand{lit: &jpLiteral{true}}, nil
+		case "false":
+			return jpOperand{lit: &jpLiteral{false}}, nil
+		case "null":
+			return jpOperand{lit: &jpLiteral{nil}}, nil
+		}
+		p.pos = start
+	}
+	return jpOperand{}, p.fail(ErrQuerySyntax)
+}
+
+// number parses a json number.
+func (p *queryParser) number() (jpOperand, error) {
+	start := p.pos
+	if _, ok := p.integer(); !ok && !p.eat("-0") {
+		return jpOperand{}, p.fail(ErrQuerySyntax)
+	}
+	digits := func() bool {
+		from := p.pos
+		for c := p.peek(); c >= '0' && c <= '9'; c = p.peek() {
+			p.pos++
+		}
+		return p.pos > from
+	}
+	if p.eat(".") && !digits() {
+		return jpOperand{}, p.fail(ErrQuerySyntax)
+	}
+	if p.eat("e") || p.eat("E") {
+		if !p.eat("+") {
+			p.eat("-")
+		}
+		if !digits() {
+			return jpOperand{}, p.fail(ErrQuerySyntax)
+		}
+	}
+	f, err := strconv.ParseFloat(p.query[start:p.pos], 64)
+	if err != nil {
+		return jpOperand{}, p.fail(ErrQuerySyntax)
+	}
+	return jpOperand{lit: &jpLiteral{f}}, nil
+}
+
+// function parses the arguments of a function call and checks their
+// types.
+func (p *queryParser) function(name string) (jpOperand, error) {
+	start := p.pos - len(name)
+	sig, known := jpFunctions[name]
+	p.eat("(")
+	args := make([]jpOperand, 0, 2)
+	for {
+		p.blank()
+		if len(args) == 0 && p.eat(")") {
+			break
+		}
+		arg, err := p.operand()
+		if err != nil {
+			return jpOperand{}, err
+		}
+		args = append(args, arg)
+		p.blank()
+		if p.eat(")") {
+			break
+		}
+		if !p.eat(",") {
+			return jpOperand{}, p.fail(ErrQuerySyntax)
+		}
+	}
+	fn := &jpFunc{name: name}
+	ok := known && len(args) == len(sig.params)
+	for i := 0; ok && i < len(args); i++ {
+		switch sig.params[i] {
+		case jpNodesType:
+			ok = args[i].q != nil
+			fn.args = append(fn.args, jpArg{q: args[i].q})
+		default:
+			var val jpComparable
+			val, ok = args[i].comparable()
+			fn.args = append(fn.args, jpArg{val: val})
+		}
+	}
+	if !ok {
+		p.pos = start
+		return jpOperand{}, p.fail(ErrQueryFunction)
+	}
+	return jpOperand{fn: fn}, nil
+}
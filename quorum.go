@@ -0,0 +1,200 @@
+package amorph
+
+// Copyright 2021 Charles J. Luciano and Scalability
+// Labs LLC. All rights reserved. Use of this source
+// code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+import "sort"
+
+// A Dissent lists the documents that disagree with the quorum at a path.
+// Value is what the quorum has there. Docs are the indexes of the
+// documents with a different value, and Missing the indexes of the
+// documents with no value at the path.
+type Dissent struct {
+	Path    Path
+	Value   Amorph
+	Docs    []int
+	Missing []int
+}
+
+// Quorum produces an Amorph with the values that are present and equal
+// in at least k of the documents. With k equal to the number of
+// documents, it is the same as IntersectionAll.
+//
+// At each path, the documents are grouped by what they have there: a
+// map, a slice, or a leaf value. If the largest group has at least k
+// documents it wins (the group that appears first wins a tie), and maps
+// and slices are compared key by key and index by index within the
+// group. A map key or slice index that fewer than k of the group have
+// is left out; slices keep NULL holes where the index exists but has no
+// quorum, as Intersection does.
+//
+// dissent reports, sorted by path, every path with a quorum where some
+// of the documents disagree or have no value, and every path where some
+// of the documents have a value but there is no quorum (Value is NULL
+// and Docs are the documents with a value), so Quorum(n/2+1, docs...)
+// finds the documents that have drifted from the majority, including
+// ones with extra keys or elements. A document that disagrees about a
+// map or slice is reported there, not below it.
+//
+// k must be between 1 and the number of documents, or Quorum returns
+// ErrQuorumSize.
+func Quorum(k int, docs ...Amorph) (ar Amorph, dissent []Dissent, err error) {
+	if k < 1 || k > len(docs) {
+		return nil, nil, ErrQuorumSize
+	}
+	idx := make([]int, len(docs))
+	for i := range idx {
+		idx[i] = i
+	}
+	dissent = make([]Dissent, 0)
+	ar = quorum(k, docs, idx, []int{}, []interface{}{}, &dissent)
+	sort.Slice(dissent, func(i, j int) bool {
+		return comparePaths(dissent[i].Path, dissent[j].Path) < 0
+	})
+	return ar, dissent, nil
+}
+
+// quorum finds the quorum of vals, the values at path in the documents
+// idx. missing are the documents in the quorum above that have no value
+// here.
+func quorum(k int, vals []Amorph, idx, missing []int, path []interface{}, dissent *[]Dissent) Amorph {
+	groups := make([][]int, 0)
+	groupOf := make(map[interface{}]int)
+	for i, v := range vals {
+		var class interface{}
+		switch v.(type) {
+		case nullType:
+			missing = append(missing, idx[i])
+			continue
+		case map[string]interface{}:
+			class = "map"
+		case []interface{}:
+			class = "slice"
+		default:
+			class = Hash(v)
+		}
+		g, ok := groupOf[class]
+		if !ok {
+			g = len(groups)
+			groupOf[class] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], i)
+	}
+	var best []int
+	bestGroup := -1
+	for g, members := range groups {
+		if len(members) > len(best) {
+			best, bestGroup = members, g
+		}
+	}
+	if len(best) < k {
+		// no quorum, so every document with a value here dissents
+		holders := make([]int, 0, len(vals))
+		for _, members := range groups {
+			for _, i := range members {
+				holders = append(holders, idx[i])
+			}
+		}
+		if len(holders) > 0 {
+			sort.Ints(holders)
+			*dissent = append(*dissent, Dissent{
+				Path:    path,
+				Value:   NULL,
+				Docs:    holders,
+				Missing: []int{},
+			})
+		}
+		return NULL
+	}
+
+	var ar Amorph
+	switch vals[best[0]].(type) {
+	case map[string]interface{}:
+		ar = mapQuorum(k, vals, idx, best, path, dissent)
+	case []interface{}:
+		ar = sliceQuorum(k, vals, idx, best, path, dissent)
+	default:
+		ar = vals[best[0]]
+	}
+
+	others := make([]int, 0)
+	for g, members := range groups {
+		if g == bestGroup {
+			continue
+		}
+		for _, i := range members {
+			others = append(others, idx[i])
+		}
+	}
+	if len(others) > 0 || len(missing) > 0 {
+		sort.Ints(others)
+		sort.Ints(missing)
+		*dissent = append(*dissent, Dissent{
+			Path:    path,
+			Value:   ar,
+			Docs:    others,
+			Missing: missing,
+		})
+	}
+	return ar
+}
+
+func mapQuorum(k int, vals []Amorph, idx, group []int, path []interface{}, dissent *[]Dissent) Amorph {
+	keys := make(map[string]struct{})
+	for _, i := range group {
+		for key := range vals[i].(map[string]interface{}) {
+			keys[key] = struct{}{}
+		}
+	}
+	ar := make(map[string]interface{})
+	for key := range keys {
+		subVals := make([]Amorph, 0, len(group))
+		subIdx := make([]int, 0, len(group))
+		missing := make([]int, 0)
+		for _, i := range group {
+			v, ok := vals[i].(map[string]interface{})[key]
+			if !ok {
+				missing = append(missing, idx[i])
+				continue
+			}
+			subVals = append(subVals, v)
+			subIdx = append(subIdx, idx[i])
+		}
+		if v := quorum(k, subVals, subIdx, missing, appendPath(path, key), dissent); v != NULL {
+			ar[key] = v
+		}
+	}
+	return ar
+}
+
+func sliceQuorum(k int, vals []Amorph, idx, group []int, path []interface{}, dissent *[]Dissent) Amorph {
+	// the slice is as long as k of the group's slices
+	lens := make([]int, 0, len(group))
+	for _, i := range group {
+		lens = append(lens, len(vals[i].([]interface{})))
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(lens)))
+	ar := NewNullSlice(lens[k-1]).([]interface{})
+	for n := 0; n < lens[0]; n++ {
+		subVals := make([]Amorph, 0, len(group))
+		subIdx := make([]int, 0, len(group))
+		missing := make([]int, 0)
+		for _, i := range group {
+			s := vals[i].([]interface{})
+			if n >= len(s) {
+				missing = append(missing, idx[i])
+				continue
+			}
+			subVals = append(subVals, s[n])
+			subIdx = append(subIdx, idx[i])
+		}
+		v := quorum(k, subVals, subIdx, missing, appendPath(path, n), dissent)
+		if n < len(ar) {
+			ar[n] = v
+		}
+	}
+	return ar
+}
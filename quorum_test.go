@@ -0,0 +1,80 @@
+package amorph_test
+
+import (
+	"testing"
+
+	"github.com/clucia/amorph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuorum(t *testing.T) {
+	docs := []amorph.Amorph{
+		map[string]interface{}{"port": 80.0, "name": "web", "tags": []interface{}{"a", "b", "c"}},
+		map[string]interface{}{"port": 80.0, "name": "web", "tags": []interface{}{"a", "x"}},
+		map[string]interface{}{"port": 8080.0, "tags": []interface{}{"a", "b", "c"}},
+	}
+	q, dissent, err := amorph.Quorum(2, docs...)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(q, map[string]interface{}{
+		"port": 80.0,
+		"name": "web",
+		"tags": []interface{}{"a", "b", "c"},
+	}))
+	assert.Equal(t, []amorph.Dissent{
+		{Path: amorph.Path{"name"}, Value: "web", Docs: []int{}, Missing: []int{2}},
+		{Path: amorph.Path{"port"}, Value: 80.0, Docs: []int{2}, Missing: []int{}},
+		{Path: amorph.Path{"tags", 1}, Value: "b", Docs: []int{1}, Missing: []int{}},
+		{Path: amorph.Path{"tags", 2}, Value: "c", Docs: []int{}, Missing: []int{1}},
+	}, dissent)
+
+	// k = n is IntersectionAll
+	q, _, err = amorph.Quorum(3, docs...)
+	assert.Nil(t, err)
+	i, err := amorph.IntersectionAll(0, docs...)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(q, i))
+
+	// no quorum on the type: the dissenter is reported at the path
+	q, dissent, err = amorph.Quorum(2, "a", []interface{}{"a"}, "a")
+	assert.Nil(t, err)
+	assert.Equal(t, "a", q)
+	assert.Equal(t, []int{1}, dissent[0].Docs)
+
+	_, _, err = amorph.Quorum(4, docs...)
+	assert.Equal(t, amorph.ErrQuorumSize, err)
+	_, _, err = amorph.Quorum(0, docs...)
+	assert.Equal(t, amorph.ErrQuorumSize, err)
+}
+
+func TestQuorumJSON(t *testing.T) {
+	data0, err := amorph.NewAmorphFromFile("test.json")
+	assert.Nil(t, err)
+	data1 := amorph.DeepCopy(data0)
+	data2 := amorph.DeepCopy(data0)
+	record := data2.([]interface{})[0].(map[string]interface{})
+	record["drift"] = "yes"
+	record["slug"] = "drifted"
+	q, dissent, err := amorph.Quorum(2, data0, data1, data2)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(q, data0))
+	assert.Equal(t, []amorph.Dissent{
+		{Path: amorph.Path{0, "drift"}, Value: amorph.NULL, Docs: []int{2}, Missing: []int{}},
+		{Path: amorph.Path{0, "slug"}, Value: "exam0", Docs: []int{2}, Missing: []int{}},
+	}, dissent)
+}
+
+func TestQuorumExtra(t *testing.T) {
+	// keys and elements beyond the quorum are drift too
+	docs := []amorph.Amorph{
+		map[string]interface{}{"a": []interface{}{1.0, 2.0}},
+		map[string]interface{}{"a": []interface{}{1.0, 2.0, 3.0}},
+		map[string]interface{}{"a": []interface{}{1.0, 2.0}, "e": "x"},
+	}
+	q, dissent, err := amorph.Quorum(3, docs...)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"a": []interface{}{1.0, 2.0}}, q)
+	assert.Equal(t, []amorph.Dissent{
+		{Path: amorph.Path{"a", 2}, Value: amorph.NULL, Docs: []int{1}, Missing: []int{}},
+		{Path: amorph.Path{"e"}, Value: amorph.NULL, Docs: []int{2}, Missing: []int{}},
+	}, dissent)
+}
@@ -0,0 +1,267 @@
+package amorph
+
+// Copyright 2021 Charles J. Luciano and Scalability
+// Labs LLC. All rights reserved. Use of this source
+// code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+import "encoding/hex"
+
+// Rebase transforms patch, which was made from the same base as onto,
+// so that it can be applied to the result of onto and still make the
+// changes it was meant to make:
+//
+//	merged, _ := PatchFwd(onto, base)
+//	rebased, _ := Rebase(patch, onto)
+//	merged, _ = PatchFwd(rebased, merged)
+//
+// Where both patches change the same value, patch wins. Where onto
+// deletes or replaces a map, slice or element that patch edits,
+// Rebase returns ErrRebaseConflict.
+//
+// Slices are rebased as splice patches (see OptDiffSplice): an element
+// that onto moved by inserting or deleting elements before it is still
+// found by the rebased patch. Positional slice patches are converted to
+// splice patches first. A positional patch that changes the length of a
+// slice records an insert or delete in the middle of it as a change to
+// every element after it, which can't be told apart from real changes,
+// so Rebase returns ErrRebaseConflict for one on either side; make
+// patches with OptDiffSplice to rebase them. The exception is two
+// positional patches that change the slice to the same length, which
+// are rebased index by index, as PatchesConflict compares them.
+//
+// If onto recorded its target with OptDiffHash, that becomes the base
+// recorded in the rebased patch.
+func Rebase(patch, onto Patch) (Patch, error) {
+	sum0, ok0 := PatchHash(DirRev, patch)
+	sum1, ok1 := PatchHash(DirRev, onto)
+	if ok0 && ok1 && sum0 != sum1 {
+		return nil, ErrPatchBaseMismatch
+	}
+	rebased, err := rebase(normalizePatch(patch, false), normalizePatch(onto, false))
+	if err != nil {
+		return nil, pathError(Path{}, err)
+	}
+	if sum, ok := PatchHash(DirFwd, onto); ok {
+		if rebased == nil {
+			rebased = map[string]interface{}{
+				"typ": "nop",
+			}
+		}
+		rebased.(map[string]interface{})["hashRev"] = hex.EncodeToString(sum[:])
+	}
+	return rebased, nil
+}
+
+func rebase(patch, onto Patch) (Patch, error) {
+	if patch == nil {
+		return nil, nil
+	}
+	if onto == nil {
+		return patch, nil
+	}
+	patchMap := patch.(map[string]interface{})
+	ontoMap := onto.(map[string]interface{})
+	typ := patchMap["typ"]
+	ontoTyp := ontoMap["typ"]
+
+	switch {
+	case typ == "raw" || typ == "string" || typ == "float64":
+		return rebaseReplace(patchMap, ontoMap)
+	case typ == "map" && ontoTyp == "map":
+		return rebaseMap(patchMap, ontoMap)
+	case isSequencePatch(typ) && isSequencePatch(ontoTyp):
+		return rebaseSplice(patchMap, ontoMap)
+	default:
+		return nil, ErrRebaseConflict
+	}
+}
+
+// rebaseReplace rebases a patch that replaces the whole value. Its
+// Forward side stands, its Reverse side becomes whatever onto left.
+func rebaseReplace(patchMap, ontoMap map[string]interface{}) (Patch, error) {
+	rebased := map[string]interface{}{
+		"typ": "raw",
+	}
+	if del, _ := patchMap["deleteFwd"].(bool); del {
+		rebased["deleteFwd"] = true
+	} else {
+		rebased["valFwd"] = patchMap["valFwd"]
+	}
+	switch ontoMap["typ"] {
+	case "raw", "string", "float64":
+		if del, _ := ontoMap["deleteFwd"].(bool); del {
+			rebased["deleteRev"] = true
+		} else {
+			rebased["valRev"] = ontoMap["valFwd"]
+		}
+	default:
+		after, err := apply(DirFwd, ontoMap, cloneAmorph(patchMap["valRev"]))
+		if err != nil {
+			return nil, err
+		}
+		rebased["valRev"] = after
+	}
+	return normalizePatch(rebased, false), nil
+}
+
+func rebaseMap(patchMap, ontoMap map[string]interface{}) (Patch, error) {
+	elems := patchMap["valFwd"].(map[string]interface{})
+	ontoElems := ontoMap["valFwd"].(map[string]interface{})
+	rebasedElems := make(map[string]interface{}, len(elems))
+	for k, v := range elems {
+		elem, err := rebase(v, ontoElems[k])
+		if err != nil {
+			return nil, prependPathError(k, err)
+		}
+		if elem != nil {
+			rebasedElems[k] = elem
+		}
+	}
+	if len(rebasedElems) == 0 {
+		return nil, nil
+	}
+	return map[string]interface{}{
+		"typ":    "map",
+		"valFwd": rebasedElems,
+	}, nil
+}
+
+// rebaseSplice moves every operation of patch from an index in the base
+// slice to the index that element has after onto. Values patch inserts
+// in the same place as onto go after the ones onto inserted.
+func rebaseSplice(patchMap, ontoMap map[string]interface{}) (Patch, error) {
+	if resizesPositional(patchMap) || resizesPositional(ontoMap) {
+		_, lenFwd, _, _, _ := unpack(DirFwd, patchMap)
+		_, ontoLenFwd, _, _, _ := unpack(DirFwd, ontoMap)
+		if patchMap["typ"] == "slice" && ontoMap["typ"] == "slice" && lenFwd == ontoLenFwd {
+			return rebaseSlice(patchMap, ontoMap)
+		}
+		return nil, ErrRebaseConflict
+	}
+	_, lenRev, _, _, _ := unpack(DirRev, patchMap)
+	_, ontoLenFwd, _, _, _ := unpack(DirFwd, ontoMap)
+	index := spliceIndex(spliceOf(patchMap))
+	ontoIndex := spliceIndex(spliceOf(ontoMap))
+
+	rebasedOps := make([]Patch, 0)
+	inserted, deleted := 0, 0
+	o := 0 // position in the slice onto produces
+	for i := 0; i <= lenRev; i++ {
+		at := index[i]
+		if at == nil {
+			at = &spliceAt{}
+		}
+		ontoAt := ontoIndex[i]
+		if ontoAt == nil {
+			ontoAt = &spliceAt{}
+		}
+
+		o += len(ontoAt.inserted)
+		if len(at.inserted) > 0 {
+			inserted += len(at.inserted)
+			merged := false
+			if n := len(rebasedOps); n > 0 {
+				// onto deleted the elements between two inserts
+				lastOp, lastIdx, lastVal := unpackOp(rebasedOps[n-1])
+				if lastOp == "insert" && lastIdx == o {
+					rebasedOps[n-1] = spliceInsertOp(o, append(lastVal.([]interface{}), at.inserted...))
+					merged = true
+				}
+			}
+			if !merged {
+				rebasedOps = append(rebasedOps, spliceInsertOp(o, at.inserted))
+			}
+		}
+		if i == lenRev {
+			break
+		}
+
+		switch ontoAt.op {
+		case "delete":
+			if at.op == "patch" {
+				return nil, prependPathError(i, ErrRebaseConflict)
+			}
+			// deleting an element onto already deleted leaves nothing to do
+			continue
+		case "patch":
+			switch at.op {
+			case "delete":
+				after, err := apply(DirFwd, ontoAt.val, cloneAmorph(at.val))
+				if err != nil {
+					return nil, prependPathError(i, err)
+				}
+				rebasedOps = append(rebasedOps, spliceDeleteOp(o, after))
+				deleted++
+			case "patch":
+				elem, err := rebase(at.val, ontoAt.val)
+				if err != nil {
+					return nil, prependPathError(i, err)
+				}
+				if elem != nil {
+					rebasedOps = append(rebasedOps, splicePatchOp(o, elem))
+				}
+			}
+		default:
+			switch at.op {
+			case "delete":
+				rebasedOps = append(rebasedOps, spliceDeleteOp(o, at.val))
+				deleted++
+			case "patch":
+				rebasedOps = append(rebasedOps, splicePatchOp(o, at.val))
+			}
+		}
+		o++
+	}
+	if len(rebasedOps) == 0 {
+		return nil, nil
+	}
+	return map[string]interface{}{
+		"typ":    "splice",
+		"valFwd": rebasedOps,
+		"lenRev": ontoLenFwd,
+		"lenFwd": ontoLenFwd + inserted - deleted,
+	}, nil
+}
+
+// resizesPositional reports whether a patch is a positional slice patch
+// that changes the length of the slice.
+func resizesPositional(patchMap map[string]interface{}) bool {
+	typ, lenFwd, _, _, _ := unpack(DirFwd, patchMap)
+	_, lenRev, _, _, _ := unpack(DirRev, patchMap)
+	return typ == "slice" && lenFwd != lenRev
+}
+
+// rebaseSlice rebases two positional patches that change a slice to the
+// same length element by element. Both patches insert or delete the
+// same indexes, so no element has moved.
+func rebaseSlice(patchMap, ontoMap map[string]interface{}) (Patch, error) {
+	_, lenFwd, _, _, _ := unpack(DirFwd, patchMap)
+	elems, _ := patchList(patchMap["valFwd"])
+	ontoElems, _ := patchList(ontoMap["valFwd"])
+	rebasedElems := make([]Patch, len(elems))
+	prune := true
+	for i, v := range elems {
+		var ontoElem Patch
+		if i < len(ontoElems) {
+			ontoElem = ontoElems[i]
+		}
+		elem, err := rebase(v, ontoElem)
+		if err != nil {
+			return nil, prependPathError(i, err)
+		}
+		if elem != nil {
+			prune = false
+		}
+		rebasedElems[i] = elem
+	}
+	if prune {
+		return nil, nil
+	}
+	return map[string]interface{}{
+		"typ":    "slice",
+		"valFwd": rebasedElems,
+		"lenFwd": lenFwd,
+		"lenRev": lenFwd,
+	}, nil
+}
@@ -0,0 +1,213 @@
+package amorph_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/clucia/amorph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffSplice(t *testing.T) {
+	data0, err := amorph.NewAmorphFromString(`["a", "b", {"k": 1}, "c", "d"]`)
+	assert.Nil(t, err)
+	data1, err := amorph.NewAmorphFromString(`["x", "a", {"k": 2}, "c", "y", "z"]`)
+	assert.Nil(t, err)
+
+	patch := amorph.Diff(data0, data1, amorph.OptDiffSplice)
+	assert.Equal(t, "splice", patch.(map[string]interface{})["typ"])
+
+	res, err := amorph.PatchFwd(patch, amorph.DeepCopy(data0))
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(res, data1))
+	res, err = amorph.PatchRev(patch, res)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(res, data0))
+
+	inv := amorph.InvertPatch(patch)
+	res, err = amorph.PatchFwd(inv, amorph.DeepCopy(data1))
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(res, data0))
+	res, err = amorph.PatchRev(inv, res)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(res, data1))
+
+	// stored as json and normalized
+	stored := amorph.NormalizePatch(amorph.DeepCopy(patch))
+	res, err = amorph.PatchFwd(stored, amorph.DeepCopy(data0))
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(res, data1))
+
+	tree0 := amorph.NewHashTree(data0)
+	tree1 := amorph.NewHashTree(data1)
+	assert.True(t, amorph.PatchEqual(patch, amorph.DiffHashTree(tree0, tree1, amorph.OptDiffSplice)))
+}
+
+func TestDiffSpliceDeep(t *testing.T) {
+	// each subtree is hashed once, not once per slice above it
+	var data0, data1 amorph.Amorph = "a", "b"
+	for i := 0; i < 1000; i++ {
+		data0 = []interface{}{"x", data0}
+		data1 = []interface{}{"x", data1}
+	}
+	patch := amorph.Diff(data0, data1, amorph.OptDiffSplice)
+	res, err := amorph.PatchFwd(patch, amorph.DeepCopy(data0))
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(res, data1))
+}
+
+func TestRebaseSplice(t *testing.T) {
+	base, err := amorph.NewAmorphFromString(`{"list": ["a", "b", "c"]}`)
+	assert.Nil(t, err)
+	mine, err := amorph.NewAmorphFromString(`{"list": ["a", "b", "C", "x"]}`)
+	assert.Nil(t, err)
+	theirs, err := amorph.NewAmorphFromString(`{"list": ["new", "b", "c"]}`)
+	assert.Nil(t, err)
+	merged, err := amorph.NewAmorphFromString(`{"list": ["new", "b", "C", "x"]}`)
+	assert.Nil(t, err)
+
+	patch := amorph.Diff(base, mine, amorph.OptDiffSplice, amorph.OptDiffHash)
+	onto := amorph.Diff(base, theirs, amorph.OptDiffSplice, amorph.OptDiffHash)
+	_, commute := amorph.PatchesConflict(patch, onto)
+	assert.True(t, commute)
+
+	rebased, err := amorph.Rebase(patch, onto)
+	assert.Nil(t, err)
+	res, err := amorph.PatchFwd(onto, amorph.DeepCopy(base))
+	assert.Nil(t, err)
+	res, err = amorph.PatchFwd(rebased, res)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(res, merged))
+
+	res, err = amorph.PatchRev(rebased, res)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(res, theirs))
+}
+
+func TestRebaseShift(t *testing.T) {
+	base, err := amorph.NewAmorphFromString(`["a", "b", "c", "d"]`)
+	assert.Nil(t, err)
+	mine, err := amorph.NewAmorphFromString(`["a", "b", "c", "D"]`)
+	assert.Nil(t, err)
+	theirs, err := amorph.NewAmorphFromString(`["b", "x", "y", "c", "d"]`)
+	assert.Nil(t, err)
+	merged, err := amorph.NewAmorphFromString(`["b", "x", "y", "c", "D"]`)
+	assert.Nil(t, err)
+
+	patch := amorph.Diff(base, mine)
+	onto := amorph.Diff(base, theirs, amorph.OptDiffSplice)
+	rebased, err := amorph.Rebase(patch, onto)
+	assert.Nil(t, err)
+	res, err := amorph.PatchFwd(onto, amorph.DeepCopy(base))
+	assert.Nil(t, err)
+	res, err = amorph.PatchFwd(rebased, res)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(res, merged))
+}
+
+func TestRebaseMap(t *testing.T) {
+	base, err := amorph.NewAmorphFromString(`{"name": "a", "config": {"addr": "10.0.0.1"}}`)
+	assert.Nil(t, err)
+	mine, err := amorph.NewAmorphFromString(`{"name": "mine", "config": {"addr": "10.0.0.2"}}`)
+	assert.Nil(t, err)
+	theirs, err := amorph.NewAmorphFromString(`{"name": "theirs", "config": {"addr": "10.0.0.1", "port": 80}}`)
+	assert.Nil(t, err)
+	merged, err := amorph.NewAmorphFromString(`{"name": "mine", "config": {"addr": "10.0.0.2", "port": 80}}`)
+	assert.Nil(t, err)
+
+	patch := amorph.Diff(base, mine)
+	onto := amorph.Diff(base, theirs)
+	rebased, err := amorph.Rebase(patch, onto)
+	assert.Nil(t, err)
+	res, err := amorph.PatchFwd(rebased, amorph.DeepCopy(theirs))
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(res, merged))
+	res, err = amorph.PatchRev(rebased, res)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(res, theirs))
+
+	removed, err := amorph.NewAmorphFromString(`{"name": "a", "config": "none"}`)
+	assert.Nil(t, err)
+	_, err = amorph.Rebase(patch, amorph.Diff(base, removed))
+	assert.True(t, errors.Is(err, amorph.ErrRebaseConflict))
+}
+
+func TestRebaseDeleteEdited(t *testing.T) {
+	base, err := amorph.NewAmorphFromString(`[{"k": 1}, {"k": 2}]`)
+	assert.Nil(t, err)
+	edited, err := amorph.NewAmorphFromString(`[{"k": 1}, {"k": 3}]`)
+	assert.Nil(t, err)
+	deleted, err := amorph.NewAmorphFromString(`[{"k": 1}]`)
+	assert.Nil(t, err)
+
+	edit := amorph.Diff(base, edited, amorph.OptDiffSplice)
+	del := amorph.Diff(base, deleted, amorph.OptDiffSplice)
+	paths, commute := amorph.PatchesConflict(edit, del)
+	assert.False(t, commute)
+	assert.Equal(t, []amorph.Path{{1}}, paths)
+
+	_, err = amorph.Rebase(edit, del)
+	assert.True(t, errors.Is(err, amorph.ErrRebaseConflict))
+
+	// the delete still wins over the edit, and can be undone
+	rebased, err := amorph.Rebase(del, edit)
+	assert.Nil(t, err)
+	res, err := amorph.PatchFwd(rebased, amorph.DeepCopy(edited))
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(res, deleted))
+	res, err = amorph.PatchRev(rebased, res)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(res, edited))
+}
+
+func TestRebasePositionalResize(t *testing.T) {
+	base := []interface{}{"a", "b", "c"}
+	onto := amorph.Diff(base, []interface{}{"x", "a", "b", "c"})
+	patch := amorph.Diff(base, []interface{}{"a", "b", "d"})
+	_, err := amorph.Rebase(patch, onto)
+	assert.True(t, errors.Is(err, amorph.ErrRebaseConflict))
+	_, err = amorph.Rebase(onto, patch)
+	assert.True(t, errors.Is(err, amorph.ErrRebaseConflict))
+
+	// with splice patches the change to "c" follows it
+	onto = amorph.Diff(base, []interface{}{"x", "a", "b", "c"}, amorph.OptDiffSplice)
+	rebased, err := amorph.Rebase(patch, onto)
+	assert.Nil(t, err)
+	res, err := amorph.PatchFwd(onto, amorph.DeepCopy(base))
+	assert.Nil(t, err)
+	res, err = amorph.PatchFwd(rebased, res)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"x", "a", "b", "d"}, res)
+}
+
+func TestRebasePositionalSameResize(t *testing.T) {
+	base := map[string]interface{}{"s": map[string]interface{}{"k0": []interface{}{}}}
+	target := map[string]interface{}{"s": map[string]interface{}{"k0": []interface{}{1.0}}}
+	patch := amorph.Diff(base, target)
+	onto := amorph.Diff(base, target)
+	_, commute := amorph.PatchesConflict(patch, onto)
+	assert.True(t, commute)
+	rebased, err := amorph.Rebase(patch, onto)
+	assert.Nil(t, err)
+	res, err := amorph.PatchFwd(onto, amorph.DeepCopy(base))
+	assert.Nil(t, err)
+	res, err = amorph.PatchFwd(rebased, res)
+	assert.Nil(t, err)
+	assert.Equal(t, target, res)
+
+	// the same length, with other changes on each side
+	slice0 := []interface{}{"a", "b"}
+	patch = amorph.Diff(slice0, []interface{}{"a", "b", "c"})
+	onto = amorph.Diff(slice0, []interface{}{"x", "b", "c"})
+	_, commute = amorph.PatchesConflict(patch, onto)
+	assert.True(t, commute)
+	for _, p := range [][2]amorph.Patch{{patch, onto}, {onto, patch}} {
+		rebased, err = amorph.Rebase(p[0], p[1])
+		assert.Nil(t, err)
+		res, err = amorph.PatchFwd(p[1], amorph.DeepCopy(slice0))
+		assert.Nil(t, err)
+		res, err = amorph.PatchFwd(rebased, res)
+		assert.Nil(t, err)
+		assert.Equal(t, []interface{}{"x", "b", "c"}, res)
+	}
+}
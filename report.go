@@ -0,0 +1,111 @@
+package amorph
+
+// Copyright 2021 Charles J. Luciano and Scalability
+// Labs LLC. All rights reserved. Use of this source
+// code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+import (
+	"fmt"
+	"sort"
+)
+
+// A Conflict is two values that Union or TopoIntersection found in the
+// same topological position, and what they were resolved to.
+// Resolution is NULL if the conflict was left out of the result.
+type Conflict struct {
+	Path       Path
+	Value0     Amorph
+	Value1     Amorph
+	Resolution Amorph
+}
+
+// UnionReport is Union, and also returns every conflict it resolved,
+// sorted by path. Two equal values are reported too unless they were
+// resolved to that value, so that a slice the options made of them both
+// can be told apart from a slice in the data.
+func UnionReport(a0, a1 Amorph, ops ...int) (ar Amorph, conflicts []Conflict, err error) {
+	options := 0
+	for _, v := range ops {
+		options = v | options
+	}
+	conflicts = make([]Conflict, 0)
+	ar, err = union(a0, a1, setOp{options: options, resolve: unionResolver(options), conflicts: &conflicts})
+	if err != nil {
+		return nil, nil, err
+	}
+	sortConflicts(conflicts)
+	return //
+}
+
+// TopoIntersectionReport is TopoIntersection, and also returns every
+// conflict it resolved, sorted by path. As for UnionReport, two equal
+// values are only reported if they weren't resolved to that value.
+func TopoIntersectionReport(a0, a1 Amorph, ops ...int) (ar Amorph, conflicts []Conflict, err error) {
+	options := 0
+	for _, v := range ops {
+		options = v | options
+	}
+	conflicts = make([]Conflict, 0)
+	ar, err = topoIntersection(a0, a1, setOp{options: options, resolve: topoIntersectionResolver(options), conflicts: &conflicts})
+	if err != nil {
+		return nil, nil, err
+	}
+	sortConflicts(conflicts)
+	return //
+}
+
+// resolveConflict resolves a conflict at the current path, and records
+// it if a report was asked for.
+func (op setOp) resolveConflict(a0, a1 Amorph) (Amorph, error) {
+	ar, err := op.resolve(op.path, a0, a1)
+	if err != nil {
+		return nil, pathError(op.path, err)
+	}
+	if op.conflicts != nil && !(DeepEqual(a0, a1) && DeepEqual(ar, a0)) {
+		*op.conflicts = append(*op.conflicts, Conflict{
+			Path:       op.path,
+			Value0:     a0,
+			Value1:     a1,
+			Resolution: ar,
+		})
+	}
+	return ar, nil
+}
+
+func sortConflicts(conflicts []Conflict) {
+	sort.Slice(conflicts, func(i, j int) bool {
+		return comparePaths(conflicts[i].Path, conflicts[j].Path) < 0
+	})
+}
+
+// comparePaths orders paths key by key. Slice indexes sort before map
+// keys, and a path sorts before the paths below it.
+func comparePaths(path0, path1 []interface{}) int {
+	for i := 0; i < len(path0) && i < len(path1); i++ {
+		i0, isInt0 := path0[i].(int)
+		i1, isInt1 := path1[i].(int)
+		switch {
+		case isInt0 && isInt1:
+			if i0 != i1 {
+				if i0 < i1 {
+					return -1
+				}
+				return 1
+			}
+		case isInt0:
+			return -1
+		case isInt1:
+			return 1
+		default:
+			k0, k1 := fmt.Sprint(path0[i]), fmt.Sprint(path1[i])
+			if k0 != k1 {
+				if k0 < k1 {
+					return -1
+				}
+				return 1
+			}
+		}
+	}
+	return len(path0) - len(path1)
+}
@@ -0,0 +1,63 @@
+package amorph_test
+
+import (
+	"testing"
+
+	"github.com/clucia/amorph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnionReport(t *testing.T) {
+	data0 := map[string]interface{}{
+		"key0": "value0",
+		"key1": "value1",
+		"key2": []interface{}{"a", "b"},
+	}
+	data1 := map[string]interface{}{
+		"key0": "value0",
+		"key1": "other",
+		"key2": []interface{}{"a", "c"},
+		"key3": "value3",
+	}
+	u, conflicts, err := amorph.UnionReport(data0, data1, amorph.OptUnionSliceResolveAmorph1)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(u, data1))
+	assert.Equal(t, []amorph.Conflict{
+		{Path: amorph.Path{"key1"}, Value0: "value1", Value1: "other", Resolution: "other"},
+		{Path: amorph.Path{"key2", 1}, Value0: "b", Value1: "c", Resolution: "c"},
+	}, conflicts)
+}
+
+func TestUnionReportEqual(t *testing.T) {
+	data0 := map[string]interface{}{"key0": "value0"}
+	data1 := map[string]interface{}{"key0": "value0"}
+
+	// the default makes a slice of the two equal values
+	u, conflicts, err := amorph.UnionReport(data0, data1)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(u, map[string]interface{}{"key0": []interface{}{"value0", "value0"}}))
+	assert.Equal(t, []amorph.Conflict{
+		{Path: amorph.Path{"key0"}, Value0: "value0", Value1: "value0", Resolution: []interface{}{"value0", "value0"}},
+	}, conflicts)
+
+	u, conflicts, err = amorph.UnionReport(data0, data1, amorph.OptUnionSliceResolveAmorph0)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(u, data0))
+	assert.Equal(t, []amorph.Conflict{}, conflicts)
+}
+
+func TestTopoIntersectionReport(t *testing.T) {
+	data0 := map[string]interface{}{
+		"key0": 1.0,
+		"key1": "only0",
+	}
+	data1 := map[string]interface{}{
+		"key0": 2.0,
+	}
+	i, conflicts, err := amorph.TopoIntersectionReport(data0, data1)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(i, map[string]interface{}{"key0": []interface{}{1.0, 2.0}}))
+	assert.Len(t, conflicts, 1)
+	assert.Equal(t, amorph.Path{"key0"}, conflicts[0].Path)
+	assert.Equal(t, []interface{}{1.0, 2.0}, conflicts[0].Resolution)
+}
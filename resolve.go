@@ -1 +1,132 @@
 package amorph
+
+// Copyright 2021 Charles J. Luciano and Scalability
+// Labs LLC. All rights reserved. Use of this source
+// code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// A Resolver decides what goes in the result when Union or
+// TopoIntersection find two values in the same topological position
+// that can't be combined by descending into them (two leaves, or two
+// values of different types). path is the Path to the conflict, and v0
+// and v1 are the values from Amorph0 and Amorph1.
+//
+// The Resolver returns the value to put in the result, NULL to leave
+// the conflict out of the result, or an error to stop the operation.
+// UnionResolve and TopoIntersectionResolve return the error in a
+// *PathError for path, unless it already is one.
+type Resolver func(path Path, v0, v1 Amorph) (Amorph, error)
+
+// setOp carries the state of a set operation down the tree.
+type setOp struct {
+	options   int
+	path      Path
+	key       string    // match the elements of slices by this field, if not ""
+	equal     LeafEqual // matches leaves, if not nil
+	resolve   Resolver
+	atomic    bool // resolve two maps or slices rather than combining them
+	patterns  []strategyPattern
+	resolver  func(options int) Resolver // the Resolver for a Strategy's options
+	conflicts *[]Conflict                // where the conflicts are reported, if not nil
+	strategic bool                       // honour the directives of StrategicMerge
+	mergeKeys []mergeKeyPattern          // the merge keys of StrategicMerge
+}
+
+// descend returns the state for the value at key.
+func (op setOp) descend(key interface{}) setOp {
+	op.path = appendPath(op.path, key)
+	return op.apply()
+}
+
+// apply switches to the Strategy for the current path, if there is one.
+func (op setOp) apply() setOp {
+	for _, sp := range op.patterns {
+		if !sp.match(op.path) {
+			continue
+		}
+		op.options = sp.strategy.Options
+		op.resolve = sp.strategy.Resolve
+		if op.resolve == nil {
+			op.resolve = op.resolver(op.options)
+		}
+		op.atomic = sp.strategy.Atomic
+		break
+	}
+	return op
+}
+
+// ResolveAmorph0 resolves every conflict with the value from Amorph0.
+func ResolveAmorph0(path Path, v0, v1 Amorph) (Amorph, error) {
+	return v0, nil
+}
+
+// ResolveAmorph1 resolves every conflict with the value from Amorph1.
+func ResolveAmorph1(path Path, v0, v1 Amorph) (Amorph, error) {
+	return v1, nil
+}
+
+// ResolveDrop leaves every conflict out of the result.
+func ResolveDrop(path Path, v0, v1 Amorph) (Amorph, error) {
+	return NULL, nil
+}
+
+// ResolveFail returns ErrConflict for any conflict that isn't two equal
+// values.
+func ResolveFail(path Path, v0, v1 Amorph) (Amorph, error) {
+	if DeepEqual(v0, v1) {
+		return v0, nil
+	}
+	return nil, ErrConflict
+}
+
+// ResolveMax resolves a conflict between two numbers with the larger
+// one. Any other conflict returns ErrConflict.
+func ResolveMax(path Path, v0, v1 Amorph) (Amorph, error) {
+	f0, ok0 := v0.(float64)
+	f1, ok1 := v1.(float64)
+	if !ok0 || !ok1 {
+		return nil, ErrConflict
+	}
+	if f1 > f0 {
+		return f1, nil
+	}
+	return f0, nil
+}
+
+// ResolveConcat resolves a conflict between two strings by
+// concatenating them. Any other conflict returns ErrConflict.
+func ResolveConcat(path Path, v0, v1 Amorph) (Amorph, error) {
+	s0, ok0 := v0.(string)
+	s1, ok1 := v1.(string)
+	if !ok0 || !ok1 {
+		return nil, ErrConflict
+	}
+	return s0 + s1, nil
+}
+
+// ResolveAppendUnique resolves a conflict between two slices with the
+// elements of the first followed by the elements of the second that
+// aren't DeepEqual to one already there. Any other conflict returns
+// ErrConflict. Use it with an Atomic Strategy, since otherwise two
+// slices are combined by index and never conflict.
+func ResolveAppendUnique(path Path, v0, v1 Amorph) (Amorph, error) {
+	s0, ok0 := v0.([]interface{})
+	s1, ok1 := v1.([]interface{})
+	if !ok0 || !ok1 {
+		return nil, ErrConflict
+	}
+	ar := make([]interface{}, 0, len(s0)+len(s1))
+	for _, v := range append(s0[:len(s0):len(s0)], s1...) {
+		dup := false
+		for _, u := range ar {
+			if DeepEqual(u, v) {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			ar = append(ar, v)
+		}
+	}
+	return ar, nil
+}
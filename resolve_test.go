@@ -0,0 +1,86 @@
+package amorph_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/clucia/amorph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnionResolve(t *testing.T) {
+	data0 := map[string]interface{}{
+		"port":  80.0,
+		"name":  "web",
+		"debug": "yes",
+		"hosts": []interface{}{"a"},
+	}
+	data1 := map[string]interface{}{
+		"port":  8080.0,
+		"name":  "server",
+		"debug": "no",
+		"hosts": []interface{}{"b", "c"},
+	}
+	res := map[string]interface{}{
+		"port":  8080.0,
+		"name":  "webserver",
+		"hosts": []interface{}{"a,b", "c"},
+	}
+	var paths [][]interface{}
+	u, err := amorph.UnionResolve(data0, data1, func(path amorph.Path, v0, v1 amorph.Amorph) (amorph.Amorph, error) {
+		paths = append(paths, path)
+		switch path[0] {
+		case "port":
+			return amorph.ResolveMax(path, v0, v1)
+		case "name":
+			return amorph.ResolveConcat(path, v0, v1)
+		case "hosts":
+			return v0.(string) + "," + v1.(string), nil
+		default:
+			return amorph.ResolveDrop(path, v0, v1)
+		}
+	})
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(u, res))
+	assert.Contains(t, paths, []interface{}{"hosts", 0})
+	assert.Len(t, paths, 4)
+
+	_, err = amorph.UnionResolve(data0, data1, amorph.ResolveFail)
+	assert.True(t, errors.Is(err, amorph.ErrConflict))
+
+	_, err = amorph.UnionResolve(data0, data1, amorph.ResolveMax)
+	assert.True(t, errors.Is(err, amorph.ErrConflict))
+}
+
+func TestUnionSliceOrder(t *testing.T) {
+	u, err := amorph.Union([]interface{}{"a"}, []interface{}{"b", "c"}, amorph.OptUnionSliceResolveAmorph0)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(u, []interface{}{"a", "c"}))
+
+	u, err = amorph.Union([]interface{}{1.0}, []interface{}{1.0}, amorph.OptUnionSliceNotEqual)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(u, []interface{}{1.0}))
+}
+
+func TestTopoIntersectionResolve(t *testing.T) {
+	data0 := map[string]interface{}{
+		"key0": 1.0,
+		"key1": "value1",
+		"key2": "only0",
+	}
+	data1 := map[string]interface{}{
+		"key0": 2.0,
+		"key1": map[string]interface{}{"nested": "value"},
+	}
+	res := map[string]interface{}{
+		"key0": 2.0,
+	}
+	i, err := amorph.TopoIntersectionResolve(data0, data1, func(path amorph.Path, v0, v1 amorph.Amorph) (amorph.Amorph, error) {
+		if _, ok := v1.(float64); ok {
+			return amorph.ResolveMax(path, v0, v1)
+		}
+		return amorph.NULL, nil
+	})
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(i, res))
+}
@@ -0,0 +1,101 @@
+package amorph
+
+// Copyright 2021 Charles J. Luciano and Scalability
+// Labs LLC. All rights reserved. Use of this source
+// code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// With OptSliceSet or OptSliceMultiset, Union, Intersection and
+// Difference treat two slices as unordered collections rather than
+// combining them by index. Elements match if they are DeepEqual,
+// whatever their position; an element that is a map or slice is
+// compared as a whole.
+//
+// With OptSliceSet an element is either in the collection or not, so
+// the results never have duplicates. With OptSliceMultiset an element
+// can be in the collection more than once: Union keeps the larger count
+// of each element, Intersection the smaller, and Difference removes one
+// element of the minuend for each match in the subtrahend. If both
+// options are set, OptSliceMultiset wins.
+//
+// The results keep the order of Amorph0 (then Amorph1 for Union) and
+// have no NULL holes.
+
+// sliceSetOption reports whether slices are unordered collections.
+func sliceSetOption(options int) bool {
+	return (OptSliceSet|OptSliceMultiset)&options > 0
+}
+
+// sliceCounts counts the elements of a slice by Hash.
+func sliceCounts(slice []interface{}) map[[32]byte]int {
+	counts := make(map[[32]byte]int, len(slice))
+	for _, v := range slice {
+		counts[Hash(v)]++
+	}
+	return counts
+}
+
+func sliceSetUnion(s0, s1 []interface{}, options int) []interface{} {
+	ar := make([]interface{}, 0, len(s0)+len(s1))
+	counts := make(map[[32]byte]int)
+	add := func(slice []interface{}) {
+		seen := make(map[[32]byte]int)
+		for _, v := range slice {
+			sum := Hash(v)
+			seen[sum]++
+			if OptSliceMultiset&options > 0 && seen[sum] > counts[sum] || counts[sum] == 0 {
+				ar = append(ar, v)
+				counts[sum]++
+			}
+		}
+	}
+	add(s0)
+	add(s1)
+	return ar
+}
+
+func sliceSetIntersection(s0, s1 []interface{}, options int) []interface{} {
+	ar := make([]interface{}, 0)
+	counts := sliceCounts(s1)
+	for _, v := range s0 {
+		sum := Hash(v)
+		if counts[sum] == 0 {
+			continue
+		}
+		ar = append(ar, v)
+		if OptSliceMultiset&options > 0 {
+			counts[sum]--
+		} else {
+			counts[sum] = 0
+		}
+	}
+	return ar
+}
+
+func sliceSetDifference(m, s []interface{}, options int) ([]interface{}, error) {
+	counts := sliceCounts(s)
+	if OptDifferenceMustSubtract&options > 0 {
+		have := sliceCounts(m)
+		for sum, n := range counts {
+			if have[sum] == 0 || OptSliceMultiset&options > 0 && have[sum] < n {
+				return nil, ErrMustSubtract
+			}
+		}
+	}
+	ar := make([]interface{}, 0, len(m))
+	for _, v := range m {
+		sum := Hash(v)
+		switch {
+		case OptSliceMultiset&options > 0 && counts[sum] > 0:
+			counts[sum]--
+			continue
+		case OptSliceMultiset&options > 0:
+		case counts[sum] != 0:
+			continue
+		default:
+			counts[sum] = -1 // keep the first copy only
+		}
+		ar = append(ar, v)
+	}
+	return ar, nil
+}
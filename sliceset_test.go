@@ -0,0 +1,66 @@
+package amorph_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/clucia/amorph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSliceSet(t *testing.T) {
+	data0 := []interface{}{"a", "b", "b", map[string]interface{}{"k": "v"}}
+	data1 := []interface{}{"b", "c", map[string]interface{}{"k": "v"}}
+
+	u, err := amorph.Union(data0, data1, amorph.OptSliceSet)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(u, []interface{}{"a", "b", map[string]interface{}{"k": "v"}, "c"}))
+
+	i, err := amorph.Intersection(data0, data1, amorph.OptSliceSet)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(i, []interface{}{"b", map[string]interface{}{"k": "v"}}))
+
+	d, err := amorph.Difference(data0, data1, amorph.OptSliceSet)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(d, []interface{}{"a"}))
+
+	_, err = amorph.Difference(data0, data1, amorph.OptSliceSet, amorph.OptDifferenceMustSubtract)
+	assert.True(t, errors.Is(err, amorph.ErrMustSubtract))
+
+	i, err = amorph.Intersection([]interface{}{"a", "b"}, []interface{}{"b", "a"}, amorph.OptSliceSet)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(i, []interface{}{"a", "b"}))
+}
+
+func TestSliceMultiset(t *testing.T) {
+	data0 := []interface{}{"a", "b", "b", "b"}
+	data1 := []interface{}{"b", "b", "c", "a", "a"}
+
+	u, err := amorph.Union(data0, data1, amorph.OptSliceMultiset)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(u, []interface{}{"a", "b", "b", "b", "c", "a"}))
+
+	i, err := amorph.Intersection(data0, data1, amorph.OptSliceMultiset)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(i, []interface{}{"a", "b", "b"}))
+
+	d, err := amorph.Difference(data0, data1[:2], amorph.OptSliceMultiset, amorph.OptDifferenceMustSubtract)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(d, []interface{}{"a", "b"}))
+
+	d, err = amorph.DifferenceAll(amorph.OptSliceMultiset, data0, []interface{}{"b"}, []interface{}{"b", "a"})
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(d, []interface{}{"b"}))
+}
+
+func TestSliceSetNested(t *testing.T) {
+	data0 := map[string]interface{}{
+		"tags": []interface{}{"x", "y"},
+	}
+	data1 := map[string]interface{}{
+		"tags": []interface{}{"y", "z"},
+	}
+	u, err := amorph.UnionAll(amorph.OptSliceSet, data0, data1, data0)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(u, map[string]interface{}{"tags": []interface{}{"x", "y", "z"}}))
+}
@@ -0,0 +1,340 @@
+package amorph
+
+// Copyright 2021 Charles J. Luciano and Scalability
+// Labs LLC. All rights reserved. Use of this source
+// code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+import "fmt"
+
+// A splice patch describes the changes to a slice as a list of
+// operations on the elements of the Reverse (base) slice:
+//
+//	{"op": "insert", "idx": i, "val": []interface{}{...}}
+//		the values are inserted before base element i
+//		(i == lenRev appends them)
+//	{"op": "delete", "idx": i, "val": v}
+//		base element i, whose value is v, is removed
+//	{"op": "patch", "idx": i, "patch": p}
+//		base element i is changed by patch p
+//
+// The operations are kept in valFwd, sorted by idx, and an insert comes
+// before a delete or patch with the same idx. Because every idx refers
+// to the base slice, inserting or deleting an element doesn't change
+// the meaning of the operations after it.
+
+// spliceDiffElems builds a splice patch from the longest common
+// subsequence of two slices. Elements are matched by hash. Runs of
+// unmatched elements are paired off and diffed with elemDiff, what's
+// left over is deleted or inserted.
+func spliceDiffElems(slice0, slice1 []interface{}, hashes0, hashes1 [][32]byte, elemDiff func(i, j int) Patch) (patch Patch) {
+	l0 := len(slice0)
+	l1 := len(slice1)
+
+	// a common prefix and suffix never need the LCS table
+	pre := 0
+	for pre < l0 && pre < l1 && hashes0[pre] == hashes1[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < l0-pre && suf < l1-pre && hashes0[l0-1-suf] == hashes1[l1-1-suf] {
+		suf++
+	}
+	n0 := l0 - pre - suf
+	n1 := l1 - pre - suf
+	lcs := make([][]int, n0+1)
+	for i := range lcs {
+		lcs[i] = make([]int, n1+1)
+	}
+	for i := n0 - 1; i >= 0; i-- {
+		for j := n1 - 1; j >= 0; j-- {
+			if hashes0[pre+i] == hashes1[pre+j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]Patch, 0)
+	var dels, ins []int
+	flush := func(gap int) {
+		paired := len(dels)
+		if len(ins) < paired {
+			paired = len(ins)
+		}
+		for k := 0; k < paired; k++ {
+			if elemPatch := elemDiff(dels[k], ins[k]); elemPatch != nil {
+				ops = append(ops, splicePatchOp(dels[k], elemPatch))
+			}
+		}
+		for _, i := range dels[paired:] {
+			ops = append(ops, spliceDeleteOp(i, slice0[i]))
+		}
+		if len(ins) > paired {
+			vals := make([]interface{}, 0, len(ins)-paired)
+			for _, j := range ins[paired:] {
+				vals = append(vals, slice1[j])
+			}
+			ops = append(ops, spliceInsertOp(gap, vals))
+		}
+		dels = dels[:0]
+		ins = ins[:0]
+	}
+	i, j := 0, 0
+	for i < n0 || j < n1 {
+		switch {
+		case i < n0 && j < n1 && hashes0[pre+i] == hashes1[pre+j]:
+			flush(pre + i)
+			i++
+			j++
+		case j < n1 && (i == n0 || lcs[i][j+1] >= lcs[i+1][j]):
+			ins = append(ins, pre+j)
+			j++
+		default:
+			dels = append(dels, pre+i)
+			i++
+		}
+	}
+	flush(pre + i)
+
+	if len(ops) == 0 {
+		return nil
+	}
+	return map[string]interface{}{
+		"typ":    "splice",
+		"valFwd": ops,
+		"lenFwd": l1,
+		"lenRev": l0,
+	}
+}
+
+func spliceInsertOp(idx int, vals []interface{}) Patch {
+	return map[string]interface{}{
+		"op":  "insert",
+		"idx": idx,
+		"val": vals,
+	}
+}
+
+func spliceDeleteOp(idx int, val interface{}) Patch {
+	return map[string]interface{}{
+		"op":  "delete",
+		"idx": idx,
+		"val": val,
+	}
+}
+
+func splicePatchOp(idx int, patch Patch) Patch {
+	return map[string]interface{}{
+		"op":    "patch",
+		"idx":   idx,
+		"patch": patch,
+	}
+}
+
+// unpackOp gets the kind, index and value (the inserted values, the
+// deleted value or the element patch) of a splice operation.
+func unpackOp(iop Patch) (op string, idx int, val interface{}) {
+	opMap, ok := iop.(map[string]interface{})
+	if !ok {
+		return //
+	}
+	op, _ = opMap["op"].(string)
+	switch ci := opMap["idx"].(type) {
+	case int:
+		idx = ci
+	case float64:
+		idx = int(ci)
+	}
+	if op == "patch" {
+		val = opMap["patch"]
+	} else {
+		val = opMap["val"]
+	}
+	return //
+}
+
+// patchList gets the element patches of a slice patch or the operations
+// of a splice patch, which are []interface{} once stored as json.
+func patchList(valX interface{}) ([]Patch, bool) {
+	switch cv := valX.(type) {
+	case []Patch:
+		return cv, true
+	case []interface{}:
+		ops := make([]Patch, len(cv))
+		for i, v := range cv {
+			ops[i] = v
+		}
+		return ops, true
+	default:
+		return nil, false
+	}
+}
+
+// spliceApply duplicates the input Amorph (a slice) to the output Amorph
+// with the operations from a splice patch applied
+func spliceApply(dir string, ipatch Patch, amorphIn Amorph) (amorphOut Amorph, err error) {
+	typ, lenX, valX, _, ok := unpack(dir, ipatch)
+	if !ok || typ != "splice" {
+		return nil, fmt.Errorf("bad type")
+	}
+	ops, ok := patchList(valX)
+	if !ok {
+		panic("Malformed patch")
+	}
+	sliceIn, _ := amorphIn.([]interface{})
+	if dir == DirFwd {
+		return spliceApplyFwd(ops, sliceIn)
+	}
+	return spliceApplyRev(ops, sliceIn, lenX)
+}
+
+func spliceApplyFwd(ops []Patch, sliceIn []interface{}) (amorphOut Amorph, err error) {
+	sliceOut := make([]interface{}, 0, len(sliceIn))
+	k := 0
+	for i := 0; i <= len(sliceIn); i++ {
+		var val interface{}
+		if i < len(sliceIn) {
+			val = sliceIn[i]
+		}
+		deleted := false
+		for ; k < len(ops); k++ {
+			op, idx, opVal := unpackOp(ops[k])
+			if idx != i {
+				break
+			}
+			switch op {
+			case "insert":
+				vals, _ := opVal.([]interface{})
+				sliceOut = append(sliceOut, vals...)
+			case "delete":
+				deleted = true
+			case "patch":
+				val, err = apply(DirFwd, opVal, val)
+				if err != nil {
+					return //
+				}
+			default:
+				panic("Malformed patch")
+			}
+		}
+		if i < len(sliceIn) && !deleted {
+			sliceOut = append(sliceOut, val)
+		}
+	}
+	if k < len(ops) {
+		return nil, fmt.Errorf("splice index out of range")
+	}
+	return sliceOut, nil
+}
+
+func spliceApplyRev(ops []Patch, sliceIn []interface{}, lenRev int) (amorphOut Amorph, err error) {
+	sliceOut := make([]interface{}, 0, lenRev)
+	o, k := 0, 0
+	for i := 0; i <= lenRev; i++ {
+		handled := false
+		for ; k < len(ops); k++ {
+			op, idx, opVal := unpackOp(ops[k])
+			if idx != i {
+				break
+			}
+			switch op {
+			case "insert":
+				vals, _ := opVal.([]interface{})
+				o += len(vals)
+			case "delete":
+				sliceOut = append(sliceOut, opVal)
+				handled = true
+			case "patch":
+				if o >= len(sliceIn) {
+					return nil, fmt.Errorf("splice index out of range")
+				}
+				val, err := apply(DirRev, opVal, sliceIn[o])
+				if err != nil {
+					return nil, err
+				}
+				sliceOut = append(sliceOut, val)
+				o++
+				handled = true
+			default:
+				panic("Malformed patch")
+			}
+		}
+		if i < lenRev && !handled {
+			if o >= len(sliceIn) {
+				return nil, fmt.Errorf("splice index out of range")
+			}
+			sliceOut = append(sliceOut, sliceIn[o])
+			o++
+		}
+	}
+	return sliceOut, nil
+}
+
+// invertSpliceOps rewrites the operations of a splice patch in terms of
+// the Forward slice, so that they turn it back into the base slice.
+func invertSpliceOps(ops []Patch) []Patch {
+	inv := make([]Patch, 0, len(ops))
+	b, o := 0, 0 // positions in the base and Forward slices
+	for _, iop := range ops {
+		op, idx, val := unpackOp(iop)
+		// elements between operations are unchanged
+		o += idx - b
+		b = idx
+		switch op {
+		case "insert":
+			vals, _ := val.([]interface{})
+			for _, v := range vals {
+				inv = append(inv, spliceDeleteOp(o, v))
+				o++
+			}
+		case "delete":
+			// consecutive deletes become a single insert
+			b++
+			if n := len(inv); n > 0 {
+				lastOp, lastIdx, lastVal := unpackOp(inv[n-1])
+				if lastOp == "insert" && lastIdx == o {
+					inv[n-1] = spliceInsertOp(o, append(lastVal.([]interface{}), val))
+					continue
+				}
+			}
+			inv = append(inv, spliceInsertOp(o, []interface{}{val}))
+		case "patch":
+			b++
+			inv = append(inv, splicePatchOp(o, InvertPatch(val)))
+			o++
+		}
+	}
+	return inv
+}
+
+// sliceToSplice rewrites a positional slice patch as splice operations.
+// Elements past the end of the Forward slice are deletes, elements past
+// the end of the base slice are inserted at the end.
+func sliceToSplice(patchMap map[string]interface{}) (ops []Patch, lenRev, lenFwd int) {
+	_, lenRev, _, _, _ = unpack(DirRev, patchMap)
+	_, lenFwd, _, _, _ = unpack(DirFwd, patchMap)
+	elems, _ := patchList(patchMap["valFwd"])
+	ops = make([]Patch, 0, len(elems))
+	var appended []interface{}
+	for i, elem := range elems {
+		if elem == nil {
+			continue
+		}
+		switch {
+		case i < lenRev && i < lenFwd:
+			ops = append(ops, splicePatchOp(i, elem))
+		case i < lenRev:
+			_, _, valRev, _, _ := unpack(DirRev, elem)
+			ops = append(ops, spliceDeleteOp(i, valRev))
+		case i < lenFwd:
+			_, _, valFwd, _, _ := unpack(DirFwd, elem)
+			appended = append(appended, valFwd)
+		}
+	}
+	if len(appended) > 0 {
+		ops = append(ops, spliceInsertOp(lenRev, appended))
+	}
+	return ops, lenRev, lenFwd
+}
@@ -0,0 +1,211 @@
+package amorph
+
+// Copyright 2021 Charles J. Luciano and Scalability
+// Labs LLC. All rights reserved. Use of this source
+// code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+import (
+	"sort"
+	"strings"
+)
+
+// StrategicMerge merges overlay into base the way Kubernetes applies a
+// strategic merge patch. It is Union with OptUnionSliceResolveAmorph1,
+// except that lists are replaced rather than combined by index, unless
+// mergeKeys gives them a merge key, and that the overlay can hold these
+// directives:
+//
+//	"key": null
+//		removes key from the map
+//	"$patch": "delete"
+//		in a map, removes the map; in a list element, removes the
+//		element with the same merge key
+//	"$patch": "replace"
+//		in a map, replaces the map rather than merging it; as a list
+//		element on its own, replaces the list with the other elements
+//	"$retainKeys": ["key", ...]
+//		removes every key of the map that isn't listed
+//	"$setElementOrder/field": [...]
+//		puts the elements of the list in field in the listed order,
+//		matched by merge key (or by value for a list of primitives);
+//		elements that aren't listed go at the end
+//	"$deleteFromPrimitiveList/field": [...]
+//		removes the listed values from the list in field
+//
+// mergeKeys maps the path patterns of lists, in the same form as the
+// keys of Strategies, to the field that identifies their elements:
+//
+//	amorph.StrategicMerge(base, overlay, map[string]string{
+//		"spec.containers":         "name",
+//		"spec.containers.*.ports": "containerPort",
+//	})
+//
+// Elements of a list with a merge key are matched by key, as by
+// UnionKeyed, and combined. Primitive elements in such a list are
+// matched by value, so giving a list of primitives any merge key (such
+// as "-") makes it the union of the two lists rather than replacing it.
+// An unknown directive returns ErrStrategicDirective.
+func StrategicMerge(base, overlay Amorph, mergeKeys map[string]string) (Amorph, error) {
+	op := setOp{
+		options:   OptUnionSliceResolveAmorph1,
+		resolve:   unionResolver(OptUnionSliceResolveAmorph1),
+		strategic: true,
+	}
+	for pattern, key := range mergeKeys {
+		op.mergeKeys = append(op.mergeKeys, mergeKeyPattern{newPathPattern(pattern), key})
+	}
+	sort.Slice(op.mergeKeys, func(i, j int) bool {
+		return op.mergeKeys[i].before(op.mergeKeys[j].pathPattern)
+	})
+	return union(base, overlay, op)
+}
+
+// mergeKeyPattern is a pattern and the merge key for the lists it
+// matches.
+type mergeKeyPattern struct {
+	pathPattern
+	key string
+}
+
+// mergeKey gets the merge key for the list at the current path.
+func (op setOp) mergeKey() string {
+	for _, mk := range op.mergeKeys {
+		if mk.match(op.path) {
+			return mk.key
+		}
+	}
+	return ""
+}
+
+// strategicUnion handles the overlay values that aren't combined with
+// the base value the way Union would: nulls and "$patch" directives,
+// and maps and lists that replace the base value, whose directives
+// still have to be removed. ok is false for everything else.
+func strategicUnion(a0, a1 Amorph, op setOp) (ar Amorph, ok bool, err error) {
+	switch ca1 := a1.(type) {
+	case nil:
+		return NULL, true, nil
+	case map[string]interface{}:
+		_, isMap := a0.(map[string]interface{})
+		switch ca1["$patch"] {
+		case nil, "merge":
+		case "delete":
+			return NULL, true, nil
+		case "replace":
+			isMap = false
+		default:
+			return nil, true, pathError(op.path, ErrStrategicDirective)
+		}
+		if !isMap {
+			ar, err = mapUnion(map[string]interface{}{}, ca1, op)
+			return ar, true, err
+		}
+	case []interface{}:
+		if _, isSlice := a0.([]interface{}); !isSlice {
+			ar, err = sliceUnion([]interface{}{}, ca1, op)
+			return ar, true, err
+		}
+	}
+	return nil, false, nil
+}
+
+// strategicSliceUnion combines two lists by merge key, or replaces the
+// base list if it doesn't have one.
+func strategicSliceUnion(s0, s1 []interface{}, op setOp) (Amorph, error) {
+	elems := make([]interface{}, 0, len(s1))
+	for _, v := range s1 {
+		if m, ok := v.(map[string]interface{}); ok && len(m) == 1 && m["$patch"] == "replace" {
+			s0 = nil
+			continue
+		}
+		elems = append(elems, v)
+	}
+	op.key = op.mergeKey()
+	if op.key == "" {
+		s0 = nil
+	}
+	return sliceKeyedUnion(s0, elems, op)
+}
+
+// strategicMapDirectives applies the directives of an overlay map to
+// the merged map.
+func strategicMapDirectives(ar, overlay map[string]interface{}, op setOp) error {
+	directives := make([]string, 0)
+	for k := range overlay {
+		if strings.HasPrefix(k, "$") {
+			directives = append(directives, k)
+		}
+	}
+	// the lists are ordered after values are removed from them
+	sort.Slice(directives, func(i, j int) bool {
+		oi := strings.HasPrefix(directives[i], "$setElementOrder/")
+		oj := strings.HasPrefix(directives[j], "$setElementOrder/")
+		if oi != oj {
+			return oj
+		}
+		return directives[i] < directives[j]
+	})
+	for _, k := range directives {
+		list, _ := overlay[k].([]interface{})
+		switch {
+		case k == "$patch":
+		case k == "$retainKeys":
+			retain := make(map[string]bool, len(list))
+			for _, v := range list {
+				if key, ok := v.(string); ok {
+					retain[key] = true
+				}
+			}
+			for key := range ar {
+				if !retain[key] {
+					delete(ar, key)
+				}
+			}
+		case strings.HasPrefix(k, "$deleteFromPrimitiveList/"):
+			field := strings.TrimPrefix(k, "$deleteFromPrimitiveList/")
+			if elems, ok := ar[field].([]interface{}); ok {
+				ar[field] = sliceSetDifferenceAllCopies(elems, list)
+			}
+		case strings.HasPrefix(k, "$setElementOrder/"):
+			field := strings.TrimPrefix(k, "$setElementOrder/")
+			if elems, ok := ar[field].([]interface{}); ok {
+				ar[field] = orderElements(elems, list, op.descend(field).mergeKey())
+			}
+		default:
+			return pathError(op.path.Append(k), ErrStrategicDirective)
+		}
+	}
+	return nil
+}
+
+// sliceSetDifferenceAllCopies removes every copy of the values in s
+// from m.
+func sliceSetDifferenceAllCopies(m, s []interface{}) []interface{} {
+	remove := sliceCounts(s)
+	ar := make([]interface{}, 0, len(m))
+	for _, v := range m {
+		if remove[Hash(v)] == 0 {
+			ar = append(ar, v)
+		}
+	}
+	return ar
+}
+
+// orderElements puts the elements of a list that match the elements of
+// order first, in that order, followed by the rest.
+func orderElements(elems, order []interface{}, key string) []interface{} {
+	match, _, matched := keyedMatch(order, elems, key)
+	ar := make([]interface{}, 0, len(elems))
+	for _, j := range match {
+		if j >= 0 {
+			ar = append(ar, elems[j])
+		}
+	}
+	for j, v := range elems {
+		if !matched[j] {
+			ar = append(ar, v)
+		}
+	}
+	return ar
+}
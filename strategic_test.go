@@ -0,0 +1,88 @@
+package amorph_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/clucia/amorph"
+	"github.com/stretchr/testify/assert"
+)
+
+var strategicMergeKeys = map[string]string{
+	"spec.containers":         "name",
+	"spec.containers.*.ports": "containerPort",
+	"metadata.finalizers":     "-",
+}
+
+func TestStrategicMerge(t *testing.T) {
+	base, err := amorph.NewAmorphFromString(`{
+		"metadata": {"name": "web", "labels": {"app": "web", "tier": "front"}, "finalizers": ["a", "b"]},
+		"spec": {
+			"containers": [
+				{"name": "app", "image": "app:1", "ports": [{"containerPort": 80, "protocol": "TCP"}]},
+				{"name": "sidecar", "image": "sidecar:1"}
+			],
+			"args": ["--old"],
+			"strategy": {"type": "RollingUpdate", "rollingUpdate": {"maxSurge": 1}}
+		}
+	}`)
+	assert.Nil(t, err)
+	overlay, err := amorph.NewAmorphFromString(`{
+		"metadata": {"labels": {"tier": null, "env": "prod"}, "finalizers": ["c"]},
+		"spec": {
+			"$setElementOrder/containers": [{"name": "sidecar"}, {"name": "app"}, {"name": "log"}],
+			"containers": [
+				{"name": "app", "image": "app:2", "ports": [{"containerPort": 443}]},
+				{"name": "log", "image": "log:1"},
+				{"name": "sidecar", "$patch": "delete"}
+			],
+			"args": ["--new"],
+			"strategy": {"$retainKeys": ["type"], "type": "Recreate"}
+		}
+	}`)
+	assert.Nil(t, err)
+	res, err := amorph.NewAmorphFromString(`{
+		"metadata": {"name": "web", "labels": {"app": "web", "env": "prod"}, "finalizers": ["a", "b", "c"]},
+		"spec": {
+			"containers": [
+				{"name": "app", "image": "app:2", "ports": [{"containerPort": 80, "protocol": "TCP"}, {"containerPort": 443}]},
+				{"name": "log", "image": "log:1"}
+			],
+			"args": ["--new"],
+			"strategy": {"type": "Recreate"}
+		}
+	}`)
+	assert.Nil(t, err)
+
+	merged, err := amorph.StrategicMerge(base, overlay, strategicMergeKeys)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(merged, res))
+}
+
+func TestStrategicMergeReplace(t *testing.T) {
+	base := map[string]interface{}{
+		"spec": map[string]interface{}{"a": "1", "b": "2"},
+		"list": []interface{}{"x", "y"},
+	}
+	overlay := map[string]interface{}{
+		"spec": map[string]interface{}{"$patch": "replace", "c": "3"},
+		"list": []interface{}{map[string]interface{}{"$patch": "replace"}, "z"},
+	}
+	merged, err := amorph.StrategicMerge(base, overlay, map[string]string{"list": "-"})
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(merged, map[string]interface{}{
+		"spec": map[string]interface{}{"c": "3"},
+		"list": []interface{}{"z"},
+	}))
+
+	overlay = map[string]interface{}{
+		"spec": map[string]interface{}{"$patch": "delete"},
+		"list": map[string]interface{}{"$bogus": "x"},
+	}
+	merged, err = amorph.StrategicMerge(base, map[string]interface{}{"spec": overlay["spec"]}, nil)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(merged, map[string]interface{}{"list": []interface{}{"x", "y"}}))
+
+	_, err = amorph.StrategicMerge(base, map[string]interface{}{"list": overlay["list"]}, nil)
+	assert.True(t, errors.Is(err, amorph.ErrStrategicDirective))
+}
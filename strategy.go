@@ -0,0 +1,152 @@
+package amorph
+
+// Copyright 2021 Charles J. Luciano and Scalability
+// Labs LLC. All rights reserved. Use of this source
+// code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A Strategy says how Union and TopoIntersection handle the subtree at
+// a path, in place of the option bits and Resolver they were called
+// with.
+type Strategy struct {
+	Options int      // the option bits for the subtree
+	Resolve Resolver // resolves the conflicts in the subtree, if not nil
+	Atomic  bool     // two maps or two slices conflict rather than being combined
+}
+
+// Strategies maps path patterns to the Strategy for the subtree at each
+// path. A pattern is a list of map keys and slice indexes separated by
+// dots, such as "config.webaddresses" or "items.0.name". A "*" matches
+// any one key or index, and a backslash escapes a dot, star or
+// backslash in a key.
+//
+// A Strategy covers its whole subtree, unless a longer pattern matches
+// further down. Where several patterns match the same path, the one with
+// a key in the first place the others have a "*" wins.
+type Strategies map[string]Strategy
+
+// UnionStrategies is Union with a Strategy for each subtree matched by
+// strategies. Everything else is handled by the option bits.
+func UnionStrategies(a0, a1 Amorph, strategies Strategies, ops ...int) (ar Amorph, err error) {
+	options := 0
+	for _, v := range ops {
+		options = v | options
+	}
+	op := setOp{options: options, resolve: unionResolver(options), resolver: unionResolver}
+	op.patterns = compileStrategies(strategies)
+	return union(a0, a1, op.apply())
+}
+
+// TopoIntersectionStrategies is TopoIntersection with a Strategy for
+// each subtree matched by strategies. Everything else is handled by the
+// option bits.
+func TopoIntersectionStrategies(a0, a1 Amorph, strategies Strategies, ops ...int) (ar Amorph, err error) {
+	options := 0
+	for _, v := range ops {
+		options = v | options
+	}
+	op := setOp{options: options, resolve: topoIntersectionResolver(options), resolver: topoIntersectionResolver}
+	op.patterns = compileStrategies(strategies)
+	return topoIntersection(a0, a1, op.apply())
+}
+
+// pathPattern is a Strategies pattern split into keys.
+type pathPattern struct {
+	keys []string
+	wild []bool
+}
+
+// strategyPattern is a pattern and the Strategy for the paths it
+// matches.
+type strategyPattern struct {
+	pathPattern
+	strategy Strategy
+}
+
+func compileStrategies(strategies Strategies) []strategyPattern {
+	patterns := make([]strategyPattern, 0, len(strategies))
+	for pattern, strategy := range strategies {
+		patterns = append(patterns, strategyPattern{newPathPattern(pattern), strategy})
+	}
+	// the first matching pattern wins
+	sort.Slice(patterns, func(i, j int) bool {
+		return patterns[i].before(patterns[j].pathPattern)
+	})
+	return patterns
+}
+
+func newPathPattern(pattern string) pathPattern {
+	keys, wild := splitPattern(pattern)
+	return pathPattern{keys: keys, wild: wild}
+}
+
+// before orders patterns so that a key sorts before a "*" in the same
+// place.
+func (pp pathPattern) before(other pathPattern) bool {
+	for k := 0; k < len(pp.wild) && k < len(other.wild); k++ {
+		if pp.wild[k] != other.wild[k] {
+			return other.wild[k]
+		}
+	}
+	return strings.Join(pp.keys, ".") < strings.Join(other.keys, ".")
+}
+
+// splitPattern splits a pattern at the dots that aren't escaped, and
+// reports which of the keys are an unescaped "*".
+func splitPattern(pattern string) (keys []string, wild []bool) {
+	if pattern == "" {
+		return //
+	}
+	var key strings.Builder
+	escaped := false
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			key.WriteByte(pattern[i])
+			escaped = true
+		case c == '.':
+			keys = append(keys, key.String())
+			wild = append(wild, !escaped && key.String() == "*")
+			key.Reset()
+			escaped = false
+		default:
+			key.WriteByte(c)
+		}
+	}
+	keys = append(keys, key.String())
+	wild = append(wild, !escaped && key.String() == "*")
+	return //
+}
+
+// match reports whether the pattern matches path exactly.
+func (pp pathPattern) match(path []interface{}) bool {
+	if len(pp.keys) != len(path) {
+		return false
+	}
+	for i, key := range path {
+		if pp.wild[i] {
+			continue
+		}
+		switch ckey := key.(type) {
+		case string:
+			if ckey != pp.keys[i] {
+				return false
+			}
+		case int:
+			if strconv.Itoa(ckey) != pp.keys[i] {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,80 @@
+package amorph_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/clucia/amorph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnionStrategies(t *testing.T) {
+	data0 := map[string]interface{}{
+		"name": "web",
+		"config": map[string]interface{}{
+			"addr":         "10.0.0.1",
+			"webaddresses": []interface{}{"a.com", "b.com"},
+			"timeout":      5.0,
+		},
+	}
+	data1 := map[string]interface{}{
+		"name": "web",
+		"config": map[string]interface{}{
+			"addr":         "10.0.0.2",
+			"webaddresses": []interface{}{"b.com", "c.com"},
+			"timeout":      10.0,
+		},
+	}
+	strategies := amorph.Strategies{
+		"config.webaddresses": {Resolve: amorph.ResolveAppendUnique, Atomic: true},
+		"config.addr":         {Options: amorph.OptUnionSliceResolveAmorph1},
+		"name":                {Resolve: amorph.ResolveFail},
+	}
+	res := map[string]interface{}{
+		"name": "web",
+		"config": map[string]interface{}{
+			"addr":         "10.0.0.2",
+			"webaddresses": []interface{}{"a.com", "b.com", "c.com"},
+			"timeout":      5.0,
+		},
+	}
+	u, err := amorph.UnionStrategies(data0, data1, strategies, amorph.OptUnionSliceResolveAmorph0)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(u, res))
+
+	data1["name"] = "api"
+	_, err = amorph.UnionStrategies(data0, data1, strategies, amorph.OptUnionSliceResolveAmorph0)
+	assert.True(t, errors.Is(err, amorph.ErrConflict))
+}
+
+func TestStrategiesWildcard(t *testing.T) {
+	data0 := map[string]interface{}{
+		"servers": []interface{}{
+			map[string]interface{}{"port": 80.0, "host": "a"},
+			map[string]interface{}{"port": 81.0, "host": "b"},
+		},
+		"a.b": "x",
+	}
+	data1 := map[string]interface{}{
+		"servers": []interface{}{
+			map[string]interface{}{"port": 8080.0, "host": "c"},
+			map[string]interface{}{"port": 8081.0, "host": "d"},
+		},
+		"a.b": "y",
+	}
+	strategies := amorph.Strategies{
+		"servers.*":      {Options: amorph.OptTopoIntersectionSliceResolveAmorph1},
+		"servers.0.host": {Resolve: amorph.ResolveDrop},
+		`a\.b`:           {Resolve: amorph.ResolveConcat},
+	}
+	res := map[string]interface{}{
+		"servers": []interface{}{
+			map[string]interface{}{"port": 8080.0},
+			map[string]interface{}{"port": 8081.0, "host": "d"},
+		},
+		"a.b": "xy",
+	}
+	i, err := amorph.TopoIntersectionStrategies(data0, data1, strategies)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(i, res))
+}
@@ -0,0 +1,109 @@
+package amorph
+
+// Copyright 2021 Charles J. Luciano and Scalability
+// Labs LLC. All rights reserved. Use of this source
+// code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+import "sort"
+
+// IsSubset reports whether every map key, slice index and leaf value of
+// a is also in b. If not, path is the first place, in sorted key order,
+// where a has something b doesn't. NULL holes in a are ignored.
+//
+// Slices are compared by index, unless OptSliceSet or OptSliceMultiset
+// is set: then every element of a must be DeepEqual to an element of b
+// (to a different one for each, with OptSliceMultiset).
+//
+// A map or slice in a, even an empty one, needs a map or slice in b at
+// the same path, so IsSubset(a, b) is true when Difference(a, b) leaves
+// nothing but empty maps and slices where b also has them. Unlike
+// Difference it stops at the first difference and doesn't build a
+// result. For a superset test, swap the arguments.
+func IsSubset(a, b Amorph, ops ...int) (ok bool, path Path) {
+	options := 0
+	for _, v := range ops {
+		options = v | options
+	}
+	return isSubset(a, b, []interface{}{}, options, false)
+}
+
+// IsTopoSubset reports whether every map key and slice index of a is
+// also in b, whatever the leaf values are. If not, path is the first
+// place, in sorted key order, where a has something b doesn't.
+func IsTopoSubset(a, b Amorph) (ok bool, path Path) {
+	return isSubset(a, b, []interface{}{}, 0, true)
+}
+
+func isSubset(a, b Amorph, path []interface{}, options int, topo bool) (bool, []interface{}) {
+	if _, hole := a.(nullType); hole {
+		return true, nil
+	}
+	if _, hole := b.(nullType); hole {
+		return false, path
+	}
+	switch ca := a.(type) {
+	case map[string]interface{}:
+		cb, ok := b.(map[string]interface{})
+		if !ok {
+			return false, path
+		}
+		keys := make([]string, 0, len(ca))
+		for k := range ca {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			vb, ok := cb[k]
+			if !ok {
+				return false, appendPath(path, k)
+			}
+			if ok, fail := isSubset(ca[k], vb, appendPath(path, k), options, topo); !ok {
+				return false, fail
+			}
+		}
+		return true, nil
+	case []interface{}:
+		cb, ok := b.([]interface{})
+		if !ok {
+			return false, path
+		}
+		if !topo && sliceSetOption(options) {
+			return sliceIsSubset(ca, cb, path, options)
+		}
+		for i, va := range ca {
+			if _, hole := va.(nullType); hole {
+				continue
+			}
+			if i >= len(cb) {
+				return false, appendPath(path, i)
+			}
+			if ok, fail := isSubset(va, cb[i], appendPath(path, i), options, topo); !ok {
+				return false, fail
+			}
+		}
+		return true, nil
+	default:
+		if topo || DeepEqual(a, b) {
+			return true, nil
+		}
+		return false, path
+	}
+}
+
+func sliceIsSubset(a, b []interface{}, path []interface{}, options int) (bool, []interface{}) {
+	counts := sliceCounts(b)
+	for i, v := range a {
+		if _, hole := v.(nullType); hole {
+			continue
+		}
+		sum := Hash(v)
+		if counts[sum] == 0 {
+			return false, appendPath(path, i)
+		}
+		if OptSliceMultiset&options > 0 {
+			counts[sum]--
+		}
+	}
+	return true, nil
+}
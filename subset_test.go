@@ -0,0 +1,69 @@
+package amorph_test
+
+import (
+	"testing"
+
+	"github.com/clucia/amorph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsSubset(t *testing.T) {
+	data0 := map[string]interface{}{
+		"key0": "value0",
+		"key1": []interface{}{"a", amorph.NULL, "c"},
+	}
+	data1 := map[string]interface{}{
+		"key0": "value0",
+		"key1": []interface{}{"a", "b", "c"},
+		"key2": nil,
+	}
+	ok, path := amorph.IsSubset(data0, data1)
+	assert.True(t, ok)
+	assert.Nil(t, path)
+
+	ok, path = amorph.IsSubset(data1, data0)
+	assert.False(t, ok)
+	assert.Equal(t, amorph.Path{"key1", 1}, path)
+
+	data0["key1"] = []interface{}{"c", "a"}
+	ok, path = amorph.IsSubset(data0, data1)
+	assert.False(t, ok)
+	assert.Equal(t, amorph.Path{"key1", 0}, path)
+
+	ok, _ = amorph.IsSubset(data0, data1, amorph.OptSliceSet)
+	assert.True(t, ok)
+
+	ok, path = amorph.IsSubset([]interface{}{"a", "a"}, []interface{}{"a"}, amorph.OptSliceMultiset)
+	assert.False(t, ok)
+	assert.Equal(t, amorph.Path{1}, path)
+
+	// an empty map in a still needs one in b, though Difference leaves
+	// only an empty map
+	a := map[string]interface{}{"a": map[string]interface{}{}}
+	ok, path = amorph.IsSubset(a, map[string]interface{}{})
+	assert.False(t, ok)
+	assert.Equal(t, amorph.Path{"a"}, path)
+	d, err := amorph.Difference(a, map[string]interface{}{})
+	assert.Nil(t, err)
+	assert.Equal(t, a, d)
+	ok, _ = amorph.IsSubset(a, map[string]interface{}{"a": map[string]interface{}{"b": 1.0}})
+	assert.True(t, ok)
+}
+
+func TestIsTopoSubset(t *testing.T) {
+	data0 := map[string]interface{}{
+		"key0": "value0",
+		"key1": map[string]interface{}{"inner": 1.0},
+	}
+	data1 := map[string]interface{}{
+		"key0": map[string]interface{}{"x": "y"},
+		"key1": map[string]interface{}{"inner": 2.0, "other": 3.0},
+	}
+	ok, path := amorph.IsTopoSubset(data0, data1)
+	assert.True(t, ok)
+	assert.Nil(t, path)
+
+	ok, path = amorph.IsTopoSubset(data1, data0)
+	assert.False(t, ok)
+	assert.Equal(t, amorph.Path{"key0"}, path)
+}
@@ -0,0 +1,58 @@
+package amorph
+
+// Copyright 2021 Charles J. Luciano and Scalability
+// Labs LLC. All rights reserved. Use of this source
+// code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// SymmetricDifference produces an Amorph that contains everything that
+// is in exactly one of the two Amorphs: the Union of the Difference of
+// each Amorph from the other.
+//
+// A leaf with different values in the two Amorphs is in both
+// Differences, so it is a conflict resolved by the Union options. Slice
+// elements that are the same in both Amorphs leave NULL holes, as they
+// do in Difference. The slice options (OptSliceSet, OptSliceMultiset)
+// are passed on to Difference and Union; OptMustSubtract is ignored.
+func SymmetricDifference(a0, a1 Amorph, ops ...int) (Amorph, error) {
+	options := 0
+	for _, v := range ops {
+		options = options | v
+	}
+	options &^= OptMustSubtract
+	return symmetricDifference(a0, a1, Difference, options)
+}
+
+// TopoSymmetricDifference produces an Amorph that contains everything
+// whose topological position is in exactly one of the two Amorphs: the
+// Union of the TopoDifference of each Amorph from the other. Since the
+// two TopoDifferences never have a value in the same place, there are
+// no conflicts.
+func TopoSymmetricDifference(a0, a1 Amorph, ops ...int) (Amorph, error) {
+	options := 0
+	for _, v := range ops {
+		options = options | v
+	}
+	options &^= OptMustSubtract
+	return symmetricDifference(a0, a1, TopoDifference, options)
+}
+
+func symmetricDifference(a0, a1 Amorph, subtract func(min, sub Amorph, ops ...int) (Amorph, error), options int) (Amorph, error) {
+	// the holes line up the two Differences, so they are only filled
+	// in the result
+	holes := options & (OptCompactNulls | OptNullsToNil)
+	options &^= holes
+	d0, err := subtract(a0, a1, options)
+	if err != nil {
+		return nil, err
+	}
+	d1, err := subtract(a1, a0, options)
+	if err != nil {
+		return nil, err
+	}
+	ar, err := Union(d0, d1, options)
+	if err != nil || holes == 0 || ar == NULL {
+		return ar, err
+	}
+	return compact(ar, holes), nil
+}
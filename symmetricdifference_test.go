@@ -0,0 +1,69 @@
+package amorph_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/clucia/amorph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSymmetricDifference(t *testing.T) {
+	data0 := map[string]interface{}{
+		"key0": "value0",
+		"key1": "value1",
+		"key2": []interface{}{"a", "b", "c"},
+	}
+	data1 := map[string]interface{}{
+		"key0": "value0",
+		"key1": "other",
+		"key2": []interface{}{"a", "x"},
+		"key3": "value3",
+	}
+	res := map[string]interface{}{
+		"key1": []interface{}{"value1", "other"},
+		"key2": []interface{}{amorph.NULL, []interface{}{"b", "x"}, "c"},
+		"key3": "value3",
+	}
+	sd, err := amorph.SymmetricDifference(data0, data1)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(sd, res))
+
+	sd, err = amorph.SymmetricDifference(data0, data1, amorph.OptSliceSet, amorph.OptUnionSliceResolveAmorph0)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(sd, map[string]interface{}{
+		"key1": "value1",
+		"key2": []interface{}{"b", "c", "x"},
+		"key3": "value3",
+	}))
+}
+
+func TestTopoSymmetricDifference(t *testing.T) {
+	data0 := map[string]interface{}{
+		"key0": "value0",
+		"key1": "value1",
+		"key2": []interface{}{"a", "b", "c"},
+	}
+	data1 := map[string]interface{}{
+		"key1": "other",
+		"key2": []interface{}{"x"},
+		"key3": "value3",
+	}
+	res := map[string]interface{}{
+		"key0": "value0",
+		"key2": []interface{}{amorph.NULL, "b", "c"},
+		"key3": "value3",
+	}
+	sd, err := amorph.TopoSymmetricDifference(data0, data1)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(sd, res))
+}
+
+func TestSymmetricDifferenceUnsupportedType(t *testing.T) {
+	data0 := map[string]interface{}{"a": true}
+	data1 := map[string]interface{}{"a": true}
+	_, err := amorph.SymmetricDifference(data0, data1)
+	assert.True(t, errors.Is(err, amorph.ErrUnsupportedType))
+	_, err = amorph.TopoSymmetricDifference(data0, data1)
+	assert.True(t, errors.Is(err, amorph.ErrUnsupportedType))
+}
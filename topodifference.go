@@ -1,23 +1,19 @@
 package amorph
 
-// Difference produces an Amorph that contains everything from the minuend
-// that isn't subtracted by the subtrahend.
-//
-// By default, the subtrahend must match all the way to the leaf values for a subtraction
-// to occur.
+// TopoDifference produces an Amorph that contains everything from the minuend
+// that doesn't have a value in topologically the same place in the subtrahend.
+// The leaf values are ignored.
 //
 // By default, elements in the subtrahend that have no topological match in the minuend
-// are ignored. The OptMustSubtract option causes Difference to treat this as an error.
-//
-// The OptIgnoreLeafValues option says that the subtraction may occur if there's ANY
-// value in topologically the same place in the two Amorphs.
-//
+// are ignored. The OptTopoDifferenceMustSubtract option causes TopoDifference to treat
+// this as an error.
 func TopoDifference(min, a1 Amorph, ops ...int) (Amorph, error) {
 	options := 0
 	for _, v := range ops {
 		options = options | v
 	}
-	return topoDifference(min, a1, options)
+	ar, err := topoDifference(min, a1, options)
+	return ar, pathError(Path{}, err)
 }
 
 func sliceTopoDifference(min, sub Amorph, options int) (Amorph, error) {
@@ -35,14 +31,14 @@ func sliceTopoDifference(min, sub Amorph, options int) (Amorph, error) {
 		if i < len(s) {
 			ar[i], err = topoDifference(v, s[i], options)
 			if err != nil {
-				return NULL, err
+				return NULL, prependPathError(i, err)
 			}
 		} else {
 			ar[i] = m[i]
 		}
 	}
 
-	return ar, nil
+	return fillHoles(ar, options), nil
 }
 
 func mapTopoDifference(min, sub Amorph, options int) (Amorph, error) {
@@ -65,20 +61,20 @@ func mapTopoDifference(min, sub Amorph, options int) (Amorph, error) {
 			ar[k] = m[k]
 		case !minOk && subOk:
 			if OptTopoDifferenceMustSubtract&options > 0 {
-				return NULL, ErrMustSubtract
+				return NULL, prependPathError(k, ErrMustSubtract)
 			}
 		case minOk && subOk:
 			var res Amorph
 			res, err = topoDifference(minv, subv, options)
 			if err != nil {
-				return NULL, err
+				return NULL, prependPathError(k, err)
 			}
 			if res == NULL {
 				continue
 			}
 			ar[k] = res
 		default:
-			panic("")
+			return NULL, prependPathError(k, ErrUnsupportedType)
 		}
 	}
 	return ar, nil
@@ -136,6 +132,6 @@ func topoDifference(min, sub Amorph, options int) (Amorph, error) {
 			return NULL, nil
 		}
 	default:
-		panic("")
+		return NULL, ErrUnsupportedType
 	}
 }
@@ -1,6 +1,7 @@
 package amorph_test
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
@@ -71,7 +72,7 @@ func TestTopoDifferencePrimitiveTypeFloat64(t *testing.T) {
 
 	diff, err = amorph.TopoDifference(amorph.NULL, data0, amorph.OptTopoDifferenceMustSubtract)
 	assert.Equal(t, diff, amorph.NULL)
-	assert.Equal(t, err, amorph.ErrMustSubtract)
+	assert.True(t, errors.Is(err, amorph.ErrMustSubtract))
 	fmt.Println("diff = ", diff, ", err = ", err)
 
 	diff, err = amorph.TopoDifference(data0, data1, amorph.OptTopoDifferenceMustSubtract)
@@ -119,7 +120,7 @@ func TestTopoDifferenceSlice(t *testing.T) {
 
 	diff, err = amorph.TopoDifference(data0, data1, amorph.OptTopoDifferenceMustSubtract)
 	assert.Equal(t, diff, amorph.NULL)
-	assert.Equal(t, err, amorph.ErrMustSubtract)
+	assert.True(t, errors.Is(err, amorph.ErrMustSubtract))
 	fmt.Println("diff = ", diff, ", err = ", err)
 
 	fmt.Println("diff = ", diff, ", err = ", err)
@@ -156,3 +157,12 @@ func TestTopoDifference001(t *testing.T) {
 	fmt.Println("diff = ", diff, ", err = ", err)
 
 }
+
+func TestTopoDifferenceUnsupportedType(t *testing.T) {
+	data := map[string]interface{}{"a": true}
+	_, err := amorph.TopoDifference(data, data)
+	assert.True(t, errors.Is(err, amorph.ErrUnsupportedType))
+	var pe *amorph.PathError
+	assert.True(t, errors.As(err, &pe))
+	assert.Equal(t, amorph.Path{"a"}, pe.Path)
+}
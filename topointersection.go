@@ -1,78 +1,56 @@
 package amorph
 
-// Intersection produces an Amorph that contains everything common to the
-// two Amorphs
+// TopoIntersection produces an Amorph that contains everything common to
+// the topology of the two Amorphs: map keys and slice indexes that
+// are present in both.
 //
-// By default, Intersection requires the leaf nodes to have the same value.
-//
-// The OptIgnoreLeafValues option tells Intersection to ignore the values.
-// This can create a situation where there are two values at the same topological location.
+// Where both Amorphs have a value in the same topological position
+// that isn't a map or slice in both, this is a conflict.
 //
 // These options resolve these conflicts:
-// OptSliceAmorph0 use the value from Amorph0
-// OptSliceAmorph1 use the value from Amorph1
-// OptSliceAlways puts the two values in a slice
-// OptSliceNotEqual puts the two values in a slice if they're not equal
-
-func amorphTopoIntersection(a0, a1 Amorph, options int) (Amorph, error) {
-	switch {
-	case OptTopoIntersectionSliceAlways&options > 0:
-		return []interface{}{a0, a1}, nil
-	case OptTopoIntersectionSliceResolveAmorph0&options > 0:
-		return a0, nil
-	case OptTopoIntersectionSliceResolveAmorph1&options > 0:
-		return a1, nil
-	default:
-		return []interface{}{a0, a1}, nil
-	}
-}
-
-func nilTopoIntersection(a0, a1 Amorph, options int) (Amorph, error) {
-	switch {
-	case OptTopoIntersectionSliceAlways&options > 0:
-		return []interface{}{a0, a1}, nil
-	case OptTopoIntersectionSliceResolveAmorph0&options > 0:
-		return a0, nil
-	case OptTopoIntersectionSliceResolveAmorph1&options > 0:
-		return a1, nil
-	case OptTopoIntersectionSliceNotEqual&options > 0 && a0 == a1:
-		return a0, nil
-	default:
-		return []interface{}{a0, a1}, nil
+// OptTopoIntersectionSliceAlways (and the default) puts the two values in a slice
+// OptTopoIntersectionSliceResolveAmorph0 use the value from Amorph0
+// OptTopoIntersectionSliceResolveAmorph1 use the value from Amorph1
+// OptTopoIntersectionSliceNotEqual puts the two values in a slice if they're not equal
+func TopoIntersection(a0, a1 Amorph, ops ...int) (ar Amorph, err error) {
+	options := 0
+	for _, v := range ops {
+		options = v | options
 	}
+	return topoIntersection(a0, a1, setOp{options: options, resolve: topoIntersectionResolver(options)})
 }
 
-func stringTopoIntersection(a0, a1 string, options int) (Amorph, error) {
-	switch {
-	case OptTopoIntersectionSliceAlways&options > 0:
-		return []interface{}{a0, a1}, nil
-	case OptTopoIntersectionSliceResolveAmorph0&options > 0:
-		return a0, nil
-	case OptTopoIntersectionSliceResolveAmorph1&options > 0:
-		return a1, nil
-	case OptTopoIntersectionSliceNotEqual&options > 0 && a0 == a1:
-		return a0, nil
-	default:
-		return []interface{}{a0, a1}, nil
+// TopoIntersectionResolve is TopoIntersection with the conflicts
+// resolved by resolve rather than by the option bits. Keys of a map
+// whose conflict resolves to NULL are left out of the result.
+func TopoIntersectionResolve(a0, a1 Amorph, resolve Resolver, ops ...int) (ar Amorph, err error) {
+	options := 0
+	for _, v := range ops {
+		options = v | options
 	}
+	return topoIntersection(a0, a1, setOp{options: options, resolve: resolve})
 }
 
-func float64TopoIntersection(a0, a1 float64, options int) (Amorph, error) {
-	switch {
-	case OptTopoIntersectionSliceAlways&options > 0:
-		return []interface{}{a0, a1}, nil
-	case OptTopoIntersectionSliceResolveAmorph0&options > 0:
-		return a0, nil
-	case OptTopoIntersectionSliceResolveAmorph1&options > 0:
-		return a1, nil
-	case OptTopoIntersectionSliceNotEqual&options > 0 && a0 == a1:
-		return a0, nil
-	default:
-		return []interface{}{a0, a1}, nil
+// topoIntersectionResolver returns the Resolver for the TopoIntersection
+// option bits.
+func topoIntersectionResolver(options int) Resolver {
+	return func(path Path, a0, a1 Amorph) (Amorph, error) {
+		switch {
+		case OptTopoIntersectionSliceAlways&options > 0:
+			return []interface{}{a0, a1}, nil
+		case OptTopoIntersectionSliceResolveAmorph0&options > 0:
+			return a0, nil
+		case OptTopoIntersectionSliceResolveAmorph1&options > 0:
+			return a1, nil
+		case OptTopoIntersectionSliceNotEqual&options > 0 && DeepEqual(a0, a1):
+			return a0, nil
+		default:
+			return []interface{}{a0, a1}, nil
+		}
 	}
 }
 
-func mapTopoIntersection(a0, a1 Amorph, options int) (Amorph, error) {
+func mapTopoIntersection(a0, a1 Amorph, op setOp) (Amorph, error) {
 	ar := make(map[string]interface{})
 	a0map := a0.(map[string]interface{})
 	a1map := a1.(map[string]interface{})
@@ -82,7 +60,7 @@ func mapTopoIntersection(a0, a1 Amorph, options int) (Amorph, error) {
 		if !a1ok {
 			continue
 		}
-		arelem, err := topoIntersection(a0elem, a1elem, options)
+		arelem, err := topoIntersection(a0elem, a1elem, op.descend(a0key))
 		if err != nil {
 			return nil, err
 		}
@@ -94,7 +72,7 @@ func mapTopoIntersection(a0, a1 Amorph, options int) (Amorph, error) {
 	return ar, nil
 }
 
-func sliceTopoIntersection(a0, a1 Amorph, options int) (Amorph, error) {
+func sliceTopoIntersection(a0, a1 Amorph, op setOp) (Amorph, error) {
 	a0Slice := a0.([]interface{})
 	a1Slice := a1.([]interface{})
 	l0 := len(a0Slice)
@@ -107,63 +85,39 @@ func sliceTopoIntersection(a0, a1 Amorph, options int) (Amorph, error) {
 	var err error
 	ar := NewNullSlice(min).([]interface{})
 	for i := 0; i < min; i++ {
-		ar[i], err = topoIntersection(a0Slice[i], a1Slice[i], options)
+		ar[i], err = topoIntersection(a0Slice[i], a1Slice[i], op.descend(i))
 		if err != nil {
 			return nil, err
 		}
 	}
-	return ar, nil
+	return fillHoles(ar, op.options), nil
 }
 
-func TopoIntersection(a0, a1 Amorph, ops ...int) (ar Amorph, err error) {
-	options := 0
-	for _, v := range ops {
-		options = v | options
+func topoIntersection(a0, a1 Amorph, op setOp) (Amorph, error) {
+	switch a1.(type) {
+	case nullType:
+		return NULL, nil
 	}
-	return topoIntersection(a0, a1, options)
-}
-
-func topoIntersection(a0, a1 Amorph, options int) (Amorph, error) {
 	switch a0.(type) {
 	case nullType:
 		return NULL, nil
-	case nil:
-		return nilTopoIntersection(a0, a1, options)
-	case string:
-		switch a1.(type) {
-		case nullType:
-			return NULL, nil
-		case string:
-			return stringTopoIntersection(a0.(string), a1.(string), options)
-		default:
-			return amorphTopoIntersection(a0, a1, options)
-		}
-	case float64:
-		switch a1.(type) {
-		case nullType:
-			return NULL, nil
-		case float64:
-			return float64TopoIntersection(a0.(float64), a1.(float64), options)
-		default:
-			return amorphTopoIntersection(a0, a1, options)
-		}
+	case nil, string, float64:
 	case map[string]interface{}:
 		switch a1.(type) {
-		case nullType:
-			return NULL, nil
 		case map[string]interface{}:
-			return mapTopoIntersection(a0, a1, options)
+			if !op.atomic {
+				return mapTopoIntersection(a0, a1, op)
+			}
 		}
-		return nil, nil
 	case []interface{}:
 		switch a1.(type) {
-		case nullType:
-			return NULL, nil
 		case []interface{}:
-			return sliceTopoIntersection(a0, a1, options)
+			if !op.atomic {
+				return sliceTopoIntersection(a0, a1, op)
+			}
 		}
 	default:
-		return nil, ErrUnsupportedType
+		return nil, pathError(op.path, ErrUnsupportedType)
 	}
-	return nil, nil
+	return op.resolveConflict(a0, a1)
 }
@@ -139,3 +139,20 @@ func TestTopoIntersectionSlice(t *testing.T) {
 	fmt.Println("intr = ", intr, ", err = ", err)
 	fmt.Println("res1 = ", res1)
 }
+
+func TestTopoIntersectionContainerLeaf(t *testing.T) {
+	// a map or slice against a leaf is a conflict like any other
+	inner := map[string]interface{}{"x": 1.0}
+	data0 := map[string]interface{}{"k": inner, "s": []interface{}{"a"}}
+	data1 := map[string]interface{}{"k": 1.0, "s": "b"}
+	intr, err := amorph.TopoIntersection(data0, data1)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"k": []interface{}{inner, 1.0},
+		"s": []interface{}{[]interface{}{"a"}, "b"},
+	}, intr)
+
+	intr, err = amorph.TopoIntersection(data0, data1, amorph.OptTopoIntersectionSliceResolveAmorph0)
+	assert.Nil(t, err)
+	assert.Equal(t, data0, intr)
+}
@@ -1,64 +1,54 @@
 package amorph
 
+import "strings"
+
+// Union combines two Amorphs. Where both Amorphs have a map, the result
+// has the keys of both maps, and where both have a slice, the result is
+// as long as the longer slice. Any other two values in the same
+// topological position are a conflict, resolved by the option bits:
+//
+// OptUnionSliceResolveAmorph0 use the value from Amorph0
+// OptUnionSliceResolveAmorph1 use the value from Amorph1
+// OptUnionSliceAlways (and the default) puts the two values in a slice
+// OptUnionSliceNotEqual puts the two values in a slice if they're not equal
 func Union(a0, a1 Amorph, ops ...int) (ar Amorph, err error) {
 	options := 0
 	for _, v := range ops {
 		options = v | options
 	}
-	return union(a0, a1, options)
+	return union(a0, a1, setOp{options: options, resolve: unionResolver(options)})
 }
 
-func amorphUnion(a0, a1 Amorph, options int) (Amorph, error) {
-	switch {
-	case OptUnionSliceResolveAmorph0&options > 0:
-		return a0, nil
-	case OptUnionSliceResolveAmorph1&options > 0:
-		return a1, nil
-	case OptUnionSliceAlways&options > 0:
-		return []interface{}{a0, a1}, nil
-	case OptUnionSliceNotEqual&options > 0 && a0 != a1:
-		return []interface{}{a0, a1}, nil
-	case OptUnionSliceNotEqual&options > 0 && a0 == a1:
-		return a0, nil
-	default:
-		return []interface{}{a0, a1}, nil
-	}
-}
-
-func stringUnion(a0, a1 string, options int) (Amorph, error) {
-	switch {
-	case OptUnionSliceResolveAmorph0&options > 0:
-		return a0, nil
-	case OptUnionSliceResolveAmorph1&options > 0:
-		return a1, nil
-	case OptUnionSliceAlways&options > 0:
-		return []interface{}{a0, a1}, nil
-	case OptUnionSliceNotEqual&options > 0 && a0 != a1:
-		return []interface{}{a0, a1}, nil
-	case OptUnionSliceNotEqual&options > 0 && a0 == a1:
-		return a0, nil
-	default:
-		return []interface{}{a0, a1}, nil
+// UnionResolve is Union with the conflicts resolved by resolve rather
+// than by the option bits. Keys of a map whose conflict resolves to
+// NULL are left out of the result.
+func UnionResolve(a0, a1 Amorph, resolve Resolver, ops ...int) (ar Amorph, err error) {
+	options := 0
+	for _, v := range ops {
+		options = v | options
 	}
+	return union(a0, a1, setOp{options: options, resolve: resolve})
 }
 
-func float64Union(a0, a1 float64, options int) (Amorph, error) {
-	switch {
-	case OptUnionSliceResolveAmorph0&options > 0:
-		return a0, nil
-	case OptUnionSliceResolveAmorph1&options > 0:
-		return a1, nil
-	case OptUnionSliceAlways&options > 0:
-		fallthrough
-	case OptUnionSliceNotEqual&options > 0 && a0 != a1:
-		fallthrough
-	default:
-		return []interface{}{a0, a1}, nil
+// unionResolver returns the Resolver for the Union option bits.
+func unionResolver(options int) Resolver {
+	return func(path Path, a0, a1 Amorph) (Amorph, error) {
+		switch {
+		case OptUnionSliceResolveAmorph0&options > 0:
+			return a0, nil
+		case OptUnionSliceResolveAmorph1&options > 0:
+			return a1, nil
+		case OptUnionSliceAlways&options > 0:
+			return []interface{}{a0, a1}, nil
+		case OptUnionSliceNotEqual&options > 0 && DeepEqual(a0, a1):
+			return a0, nil
+		default:
+			return []interface{}{a0, a1}, nil
+		}
 	}
 }
 
-func mapUnion(a0, a1 Amorph, options int) (Amorph, error) {
-	var err error
+func mapUnion(a0, a1 Amorph, op setOp) (Amorph, error) {
 	keys := make(map[string]struct{})
 	for k := range a0.(map[string]interface{}) {
 		keys[k] = struct{}{}
@@ -72,99 +62,272 @@ func mapUnion(a0, a1 Amorph, options int) (Amorph, error) {
 		v1, ok1 := a1.(map[string]interface{})[k]
 
 		switch {
+		case op.strategic && strings.HasPrefix(k, "$"):
+			continue
 		case ok0 && !ok1:
 			ar[k] = v0
-		case !ok0 && ok1:
+		case !ok0 && ok1 && !op.strategic:
 			ar[k] = v1
+		case !ok0 && ok1:
+			v0 = NULL
+			fallthrough
 		case ok0 && ok1:
-			ar[k], err = union(v0, v1, options)
+			arelem, err := union(v0, v1, op.descend(k))
 			if err != nil {
 				return nil, err
 			}
+			if arelem == NULL {
+				continue
+			}
+			ar[k] = arelem
+		}
+	}
+	if op.strategic {
+		if err := strategicMapDirectives(ar, a1.(map[string]interface{}), op); err != nil {
+			return nil, err
 		}
 	}
 	return ar, nil
 }
 
-func sliceUnion(a0, a1 Amorph, options int) (Amorph, error) {
+func sliceUnion(a0, a1 Amorph, op setOp) (Amorph, error) {
 	var err error
 	a0slice := a0.([]interface{})
 	a1slice := a1.([]interface{})
-	long1 := a0slice
-	short1 := a1slice
-
-	if len(a0slice) < len(a1slice) {
-		long1 = a1slice
-		short1 = a0slice
-	}
-	ar := NewNullSlice(len(long1)).([]interface{})
-	for i, v0 := range long1 {
-		if i < len(short1) {
-			v1 := a1.([]interface{})[i]
-			ar[i], err = union(v0, v1, options)
+	switch {
+	case op.strategic:
+		return strategicSliceUnion(a0slice, a1slice, op)
+	case op.key != "":
+		return sliceKeyedUnion(a0slice, a1slice, op)
+	case sliceSetOption(op.options):
+		return sliceSetUnion(a0slice, a1slice, op.options), nil
+	}
+	ar := NewNullSlice(max(len(a0slice), len(a1slice))).([]interface{})
+	for i := range ar {
+		switch {
+		case i >= len(a1slice):
+			ar[i] = a0slice[i]
+		case i >= len(a0slice):
+			ar[i] = a1slice[i]
+		default:
+			ar[i], err = union(a0slice[i], a1slice[i], op.descend(i))
 			if err != nil {
 				return nil, err
 			}
-		} else {
-			ar[i] = v0
 		}
 	}
-	return ar, nil
+	return fillHoles(ar, op.options), nil
 }
 
-func union(a0, a1 Amorph, options int) (ar Amorph, err error) {
-	switch ca0 := a0.(type) {
-	case nullType:
-		switch a1.(type) {
-		case nullType:
-			return NULL, nil
-		default:
-			return a1, nil
+func union(a0, a1 Amorph, op setOp) (ar Amorph, err error) {
+	if op.strategic {
+		if ar, ok, err := strategicUnion(a0, a1, op); ok {
+			return ar, err
 		}
-	case nil:
+	}
+	switch a0.(type) {
+	case nullType:
+		return a1, nil
+	case nil, string, float64:
 		switch a1.(type) {
 		case nullType:
 			return a0, nil
-		default:
-			return amorphUnion(a0, a1, options)
-		}
-	case string:
-		switch ca1 := a1.(type) {
-		case nullType:
-			return a0, nil
-		case string:
-			return stringUnion(ca0, ca1, options)
-		default:
-			return amorphUnion(a0, a1, options)
-		}
-	case float64:
-		switch ca1 := a1.(type) {
-		case nullType:
-			return a0, nil
-		case float64:
-			return float64Union(ca0, ca1, options)
-		default:
-			return amorphUnion(a0, a1, options)
 		}
 	case map[string]interface{}:
 		switch a1.(type) {
 		case nullType:
 			return a0, nil
 		case map[string]interface{}:
-			return mapUnion(a0, a1, options)
-		default:
-			return amorphUnion(a0, a1, options)
+			if !op.atomic {
+				return mapUnion(a0, a1, op)
+			}
 		}
 	case []interface{}:
 		switch a1.(type) {
 		case nullType:
 			return a0, nil
 		case []interface{}:
-			return sliceUnion(a0, a1, options)
+			if !op.atomic {
+				return sliceUnion(a0, a1, op)
+			}
+		}
+	default:
+		return nil, pathError(op.path, ErrUnsupportedType)
+	}
+	return op.resolveConflict(a0, a1)
+}
+
+// UnionAll combines any number of Amorphs in a single pass, without
+// building the intermediate results of nested Union calls.
+//
+// The Amorphs are folded left to right, as though by
+// Union(Union(a0, a1), a2)..., except that a conflict between more than
+// two values is resolved in one step. Maps (or slices) next to each
+// other are merged first, as the nested calls would, and then:
+//
+// OptUnionSliceResolveAmorph0 keeps the first value, merged with every later map or slice like it
+// OptUnionSliceResolveAmorph1 keeps the last value, merged with the maps or slices like it just before it
+// OptUnionSliceAlways (and the default) puts all of the values in one slice
+// OptUnionSliceNotEqual puts all of the values in one slice, unless they're all equal
+//
+// With the first two, the result is always the same as the nested Union
+// calls. NULL holes are skipped, so they never take part in a conflict.
+//
+// Unlike Union, the options come first, as a single int (0 for none),
+// since the Amorphs take the variadic parameter.
+func UnionAll(ops int, amorphs ...Amorph) (ar Amorph, err error) {
+	ar, err = unionAll(amorphs, ops)
+	return ar, pathError(Path{}, err)
+}
+
+func unionAll(amorphs []Amorph, options int) (Amorph, error) {
+	vals := make([]interface{}, 0, len(amorphs))
+	for _, a := range amorphs {
+		if _, ok := a.(nullType); !ok {
+			vals = append(vals, a)
+		}
+	}
+	switch len(vals) {
+	case 0:
+		return NULL, nil
+	case 1:
+		return vals[0], nil
+	}
+	allMaps, allSlices := true, true
+	for _, v := range vals {
+		switch v.(type) {
+		case map[string]interface{}:
+			allSlices = false
+		case []interface{}:
+			allMaps = false
+		case nil, string, float64:
+			allMaps = false
+			allSlices = false
 		default:
-			return amorphUnion(a0, a1, options)
+			return nil, ErrUnsupportedType
 		}
+	}
+	switch {
+	case allMaps:
+		return mapUnionAll(vals, options)
+	case allSlices:
+		return sliceUnionAll(vals, options)
 	default:
-		return nil, ErrUnsupportedType
+		return amorphUnionAll(vals, options)
+	}
+}
+
+// amorphUnionAll resolves values that aren't all maps or all slices,
+// giving the same result as the nested Union calls would: maps (or
+// slices) that the fold would meet one after the other are merged
+// before the conflict is resolved.
+func amorphUnionAll(vals []interface{}, options int) (Amorph, error) {
+	switch {
+	case OptUnionSliceResolveAmorph0&options > 0:
+		// the first value is kept, and merged with every later one of
+		// its kind
+		kind := unionKind(vals[0])
+		if kind == "leaf" {
+			return vals[0], nil
+		}
+		run := make([]Amorph, 0, len(vals))
+		for _, v := range vals {
+			if unionKind(v) == kind {
+				run = append(run, v)
+			}
+		}
+		return unionAll(run, options)
+	case OptUnionSliceResolveAmorph1&options > 0:
+		// each conflict starts over from the later value
+		last := len(vals) - 1
+		kind := unionKind(vals[last])
+		if kind == "leaf" {
+			return vals[last], nil
+		}
+		run := []Amorph{vals[last]}
+		for i := last - 1; i >= 0 && unionKind(vals[i]) == kind; i-- {
+			run = append([]Amorph{vals[i]}, run...)
+		}
+		return unionAll(run, options)
+	}
+	merged := make([]interface{}, 0, len(vals))
+	for start := 0; start < len(vals); {
+		kind := unionKind(vals[start])
+		run := []Amorph{vals[start]}
+		for start++; kind != "leaf" && start < len(vals) && unionKind(vals[start]) == kind; start++ {
+			run = append(run, vals[start])
+		}
+		v, err := unionAll(run, options)
+		if err != nil {
+			return nil, err
+		}
+		merged = append(merged, v)
+	}
+	if OptUnionSliceNotEqual&options > 0 {
+		for _, v := range merged[1:] {
+			if !DeepEqual(merged[0], v) {
+				return merged, nil
+			}
+		}
+		return merged[0], nil
+	}
+	return merged, nil
+}
+
+func unionKind(v Amorph) string {
+	switch v.(type) {
+	case map[string]interface{}:
+		return "map"
+	case []interface{}:
+		return "slice"
+	}
+	return "leaf"
+}
+
+func mapUnionAll(vals []interface{}, options int) (Amorph, error) {
+	keyVals := make(map[string][]Amorph)
+	for _, v := range vals {
+		for k, kv := range v.(map[string]interface{}) {
+			keyVals[k] = append(keyVals[k], kv)
+		}
+	}
+	var err error
+	ar := make(map[string]interface{}, len(keyVals))
+	for k, kvs := range keyVals {
+		ar[k], err = unionAll(kvs, options)
+		if err != nil {
+			return nil, prependPathError(k, err)
+		}
+	}
+	return ar, nil
+}
+
+func sliceUnionAll(vals []interface{}, options int) (Amorph, error) {
+	if sliceSetOption(options) {
+		ar := make([]interface{}, 0)
+		for _, v := range vals {
+			ar = sliceSetUnion(ar, v.([]interface{}), options)
+		}
+		return ar, nil
+	}
+	long := 0
+	for _, v := range vals {
+		long = max(long, len(v.([]interface{})))
+	}
+	var err error
+	ar := NewNullSlice(long).([]interface{})
+	idxVals := make([]Amorph, 0, len(vals))
+	for i := range ar {
+		idxVals = idxVals[:0]
+		for _, v := range vals {
+			if s := v.([]interface{}); i < len(s) {
+				idxVals = append(idxVals, s[i])
+			}
+		}
+		ar[i], err = unionAll(idxVals, options)
+		if err != nil {
+			return nil, prependPathError(i, err)
+		}
 	}
+	return fillHoles(ar, options), nil
 }
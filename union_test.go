@@ -119,3 +119,111 @@ func TestUnion001(t *testing.T) {
 	u, err = amorph.Union(data0, data1, amorph.OptUnionSliceNotEqual)
 	fmt.Println("u = ", u, ", err = ", err)
 }
+
+func TestUnionAll(t *testing.T) {
+	layers := []amorph.Amorph{
+		map[string]interface{}{
+			"name": "base",
+			"port": 80.0,
+			"tags": []interface{}{"a"},
+		},
+		map[string]interface{}{
+			"port": 8080.0,
+			"tags": []interface{}{"b", "c"},
+		},
+		map[string]interface{}{
+			"name": "prod",
+			"host": "example.com",
+		},
+	}
+	res0 := map[string]interface{}{
+		"name": "prod",
+		"port": 8080.0,
+		"host": "example.com",
+		"tags": []interface{}{"b", "c"},
+	}
+	u, err := amorph.UnionAll(amorph.OptUnionSliceResolveAmorph1, layers...)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(u, res0))
+
+	res1 := map[string]interface{}{
+		"name": []interface{}{"base", "prod"},
+		"port": []interface{}{80.0, 8080.0},
+		"host": "example.com",
+		"tags": []interface{}{[]interface{}{"a", "b"}, "c"},
+	}
+	u, err = amorph.UnionAll(amorph.OptUnionSliceNotEqual, layers...)
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(u, res1))
+
+	u, err = amorph.UnionAll(0, "x", "y", "z")
+	assert.Nil(t, err)
+	assert.True(t, amorph.DeepEqual(u, []interface{}{"x", "y", "z"}))
+
+	u, err = amorph.UnionAll(0)
+	assert.Nil(t, err)
+	assert.Equal(t, u, amorph.NULL)
+}
+
+func TestUnionAllMixedTypes(t *testing.T) {
+	m0 := map[string]interface{}{"a": 1.0}
+	m1 := map[string]interface{}{"b": 2.0}
+	both := map[string]interface{}{"a": 1.0, "b": 2.0}
+
+	u, err := amorph.UnionAll(amorph.OptUnionSliceResolveAmorph0, m0, m1, "s")
+	assert.Nil(t, err)
+	assert.Equal(t, both, u)
+	u, err = amorph.UnionAll(amorph.OptUnionSliceResolveAmorph1, "s", m0, m1)
+	assert.Nil(t, err)
+	assert.Equal(t, both, u)
+
+	// the same as the nested Union calls
+	for _, ops := range []int{amorph.OptUnionSliceResolveAmorph0, amorph.OptUnionSliceResolveAmorph1} {
+		for _, vals := range [][]amorph.Amorph{
+			{m0, m1, "s"},
+			{"s", m0, m1},
+			{m0, "s", m1},
+			{"s", m0, "t"},
+			{[]interface{}{"x"}, m0, []interface{}{nil, "y"}},
+		} {
+			nested := amorph.DeepCopy(vals[0])
+			for _, v := range vals[1:] {
+				nested, err = amorph.Union(nested, amorph.DeepCopy(v), ops)
+				assert.Nil(t, err)
+			}
+			u, err = amorph.UnionAll(ops, vals...)
+			assert.Nil(t, err)
+			assert.Equal(t, nested, u, "%d %v", ops, vals)
+		}
+	}
+
+	// maps next to each other are merged before the conflict
+	u, err = amorph.UnionAll(amorph.OptUnionSliceAlways, m0, m1, "s")
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{both, "s"}, u)
+	u, err = amorph.UnionAll(amorph.OptUnionSliceNotEqual, m0, "s", m1)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{m0, "s", m1}, u)
+}
+
+func TestUnionSliceNotEqual(t *testing.T) {
+	// equal leaves of any type give one value
+	for _, v := range []amorph.Amorph{1.0, "s", nil} {
+		u, err := amorph.Union(v, v, amorph.OptUnionSliceNotEqual)
+		assert.Nil(t, err)
+		assert.Equal(t, v, u)
+	}
+	u, err := amorph.Union(1.0, 2.0, amorph.OptUnionSliceNotEqual)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{1.0, 2.0}, u)
+}
+
+func TestUnionLongerAmorph1(t *testing.T) {
+	// the elements both slices have are combined from both
+	u, err := amorph.Union([]interface{}{"a"}, []interface{}{"b", "c"})
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{[]interface{}{"a", "b"}, "c"}, u)
+	u, err = amorph.Union([]interface{}{"a", "b", "c"}, []interface{}{"x"}, amorph.OptUnionSliceResolveAmorph1)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"x", "b", "c"}, u)
+}
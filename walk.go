@@ -84,6 +84,30 @@ type WalkIter interface {
 	Copy() WalkIter               // Copy duplicates a WalkIter
 }
 
+// A PathWalkIter is a WalkIter that can also give its Path. The
+// iterators that Walk and WalkMatch pass to their function are
+// PathWalkIters; use IterPath to get the Path of any WalkIter.
+type PathWalkIter interface {
+	WalkIter
+	Path() Path // Path gets the keys from the top of the Amorph down to Top
+}
+
+// IterPath returns the map keys and slice indexes from the top of the
+// Amorph down to the Top WalkPos of iter.
+func IterPath(iter WalkIter) Path {
+	if pi, ok := iter.(PathWalkIter); ok {
+		return pi.Path()
+	}
+	path := make(Path, iter.Len())
+	for i, pi := len(path)-1, iter.Copy(); i >= 0; i, pi = i-1, pi.Pop() {
+		path[i] = pi.Top().Key()
+	}
+	if len(path) > 0 && path[0] == nil {
+		path = path[1:]
+	}
+	return path
+}
+
 type sliceWalkIter []WalkPos
 
 // Copy duplicates an iterator
@@ -119,6 +143,20 @@ func (wi *sliceWalkIter) Top() WalkPos {
 	return (*wi)[l-1]
 }
 
+// Path gets the map keys and slice indexes from the top of the Amorph
+// down to the Top WalkPos. The layer Walk starts with, for the whole
+// Amorph, has no key.
+func (wi *sliceWalkIter) Path() Path {
+	path := make(Path, 0, len(*wi))
+	for i, wp := range *wi {
+		if i == 0 && wp.Key() == nil {
+			continue
+		}
+		path = append(path, wp.Key())
+	}
+	return path
+}
+
 // Append creates a new iterator with a new layer appended
 func (wi *sliceWalkIter) Append(elem WalkPos) WalkIter {
 	(*wi) = append(*wi, elem)
@@ -182,10 +220,10 @@ func walk(val interface{}, iter WalkIter, wfunc func(iter WalkIter) error) (err
 		}
 		return //
 	default:
-		niter = NewSliceWalkIter().Append(NewWalkPos(nil, val))
-		err = wfunc(niter)
-		if err != nil {
-			return //
+		// the map or slice holding a leaf has already visited it, so
+		// only a leaf at the top is visited here
+		if iter.Len() == 1 {
+			err = wfunc(iter)
 		}
 	}
 	return //
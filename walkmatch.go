@@ -0,0 +1,218 @@
+package amorph
+
+// Copyright 2021 Charles J. Luciano and Scalability
+// Labs LLC. All rights reserved. Use of this source
+// code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WalkMatch is Walk, except that wfunc is only called for the nodes
+// whose Path matches pattern, and subtrees that can't hold a match
+// aren't visited at all.
+//
+// A pattern is written like a Path (see Path.String), with or without
+// the leading ".": "config.webaddresses[1]". In place of a key or index
+// it can have "*", which matches any one map key or slice index, "[*]",
+// which matches any one slice index, or "**", which matches any number
+// of keys and indexes, including none.
+//
+// A key also matches the slice index with the same digits, as in
+// Strategies, and a backslash escapes a ".", "[", "*" or backslash in a
+// key. The top of the Amorph is visited if the pattern matches the
+// empty Path, as "" and "**" do.
+//
+// WalkMatch returns ErrPatternSyntax if the pattern can't be read.
+func WalkMatch(in interface{}, pattern string, wfunc func(iter WalkIter) error) error {
+	glob, err := parseGlob(pattern)
+	if err != nil {
+		return err
+	}
+	iter := NewSliceWalkIter().Append(NewWalkPos(nil, in))
+	states := glob.start()
+	if glob.matched(states) {
+		if err := wfunc(iter); err != nil {
+			return err
+		}
+	}
+	return walkMatch(in, iter, glob, states, wfunc)
+}
+
+func walkMatch(val interface{}, iter WalkIter, glob globPattern, states []int, wfunc func(iter WalkIter) error) error {
+	visit := func(k, v interface{}) error {
+		next := glob.step(states, k)
+		if len(next) == 0 {
+			return nil // nothing below can match
+		}
+		niter := iter.Copy().Append(NewWalkPos(k, v))
+		if glob.matched(next) {
+			if err := wfunc(niter); err != nil {
+				return err
+			}
+		}
+		return walkMatch(v, niter, glob, next, wfunc)
+	}
+	switch typedIn := val.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(typedIn))
+		for k := range typedIn {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err := visit(k, typedIn[k]); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for i, v := range typedIn {
+			if err := visit(i, v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+type globKind int
+
+const (
+	globKey      globKind = iota // a map key, or the slice index with the same digits
+	globIndex                    // a slice index
+	globAny                      // *
+	globAnyIndex                 // [*]
+	globDeep                     // **
+)
+
+type globSegment struct {
+	kind  globKind
+	key   string
+	index int
+}
+
+// globPattern is a parsed WalkMatch pattern. It is matched a key at a
+// time, keeping the set of places in the pattern that the keys so far
+// can have reached.
+type globPattern []globSegment
+
+func (gp globPattern) start() []int {
+	return gp.closure([]int{0})
+}
+
+// closure adds the places reached by matching a "**" to no keys.
+func (gp globPattern) closure(states []int) []int {
+	for i := 0; i < len(states); i++ {
+		if s := states[i]; s < len(gp) && gp[s].kind == globDeep && !containsInt(states, s+1) {
+			states = append(states, s+1)
+		}
+	}
+	return states
+}
+
+// step returns the places reached from states by matching key.
+func (gp globPattern) step(states []int, key interface{}) []int {
+	next := make([]int, 0, len(states))
+	add := func(s int) {
+		if !containsInt(next, s) {
+			next = append(next, s)
+		}
+	}
+	for _, s := range states {
+		if s == len(gp) {
+			continue
+		}
+		if gp[s].kind == globDeep {
+			add(s)
+		} else if gp[s].match(key) {
+			add(s + 1)
+		}
+	}
+	return gp.closure(next)
+}
+
+// matched reports whether states includes the end of the pattern.
+func (gp globPattern) matched(states []int) bool {
+	return containsInt(states, len(gp))
+}
+
+func (seg globSegment) match(key interface{}) bool {
+	i, isIndex := key.(int)
+	switch seg.kind {
+	case globKey:
+		if isIndex {
+			return strconv.Itoa(i) == seg.key
+		}
+		return key == seg.key
+	case globIndex:
+		return isIndex && i == seg.index
+	case globAnyIndex:
+		return isIndex
+	default:
+		return true
+	}
+}
+
+func containsInt(s []int, v int) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
+// parseGlob reads a WalkMatch pattern, in the form of Path.String with
+// wildcards.
+func parseGlob(pattern string) (globPattern, error) {
+	gp := globPattern{}
+	for i := 0; i < len(pattern); {
+		if pattern[i] == '[' {
+			end := strings.IndexByte(pattern[i:], ']')
+			if end < 0 {
+				return nil, ErrPatternSyntax
+			}
+			index := pattern[i+1 : i+end]
+			i += end + 1
+			if index == "*" {
+				gp = append(gp, globSegment{kind: globAnyIndex})
+				continue
+			}
+			n, err := strconv.Atoi(index)
+			if err != nil || n < 0 || strconv.Itoa(n) != index {
+				return nil, ErrPatternSyntax
+			}
+			gp = append(gp, globSegment{kind: globIndex, index: n})
+			continue
+		}
+		if pattern[i] == '.' {
+			i++
+		} else if i > 0 {
+			return nil, ErrPatternSyntax
+		}
+		var key strings.Builder
+		escaped := false
+		for ; i < len(pattern) && pattern[i] != '.' && pattern[i] != '['; i++ {
+			if pattern[i] == '\\' {
+				if i+1 == len(pattern) {
+					return nil, ErrPatternSyntax
+				}
+				i++
+				escaped = true
+			}
+			key.WriteByte(pattern[i])
+		}
+		switch {
+		case !escaped && key.String() == "*":
+			gp = append(gp, globSegment{kind: globAny})
+		case !escaped && key.String() == "**":
+			gp = append(gp, globSegment{kind: globDeep})
+		default:
+			gp = append(gp, globSegment{kind: globKey, key: key.String()})
+		}
+	}
+	return gp, nil
+}
@@ -0,0 +1,63 @@
+package amorph_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/clucia/amorph"
+	"github.com/stretchr/testify/assert"
+)
+
+// matchPaths returns the Paths WalkMatch visits, as strings.
+func matchPaths(t *testing.T, a amorph.Amorph, pattern string) []string {
+	paths := make([]string, 0)
+	err := amorph.WalkMatch(a, pattern, func(iter amorph.WalkIter) error {
+		paths = append(paths, amorph.IterPath(iter).String())
+		return nil
+	})
+	assert.Nil(t, err, pattern)
+	return paths
+}
+
+func TestWalkMatch(t *testing.T) {
+	data, err := amorph.NewAmorphFromFile("test.json")
+	assert.Nil(t, err)
+
+	assert.Equal(t, []string{"[1].config.webaddresses[0]", "[1].config.webaddresses[1]", "[1].config.webaddresses[2]"},
+		matchPaths(t, data, "[1].config.webaddresses[*]"))
+	assert.Equal(t, []string{"[0].slug", "[1].slug"}, matchPaths(t, data, "*.slug"))
+	assert.Equal(t, []string{"[0].slug", "[1].slug"}, matchPaths(t, data, "**.slug"))
+	assert.Equal(t, []string{"[0].config.webaddresses[2]", "[1].config.webaddresses[2]"}, matchPaths(t, data, "**.webaddresses.2"))
+	assert.Equal(t, []string{}, matchPaths(t, data, "config.addr"))
+	assert.Equal(t, []string{""}, matchPaths(t, data, ""))
+
+	// ** matches no keys as well as many
+	doc := map[string]interface{}{"a": map[string]interface{}{"a": map[string]interface{}{"b": 1.0}}, "b": 2.0}
+	assert.Equal(t, []string{".a.a.b", ".b"}, matchPaths(t, doc, "**.b"))
+	assert.Equal(t, []string{"", ".a", ".a.a", ".a.a.b", ".b"}, matchPaths(t, doc, "**"))
+	assert.Equal(t, []string{".a.a.b"}, matchPaths(t, doc, "a.**.b"))
+
+	// escaped keys
+	doc = map[string]interface{}{"a.b": 1.0, "*": 2.0, "c": 3.0}
+	assert.Equal(t, []string{`.a\.b`}, matchPaths(t, doc, `a\.b`))
+	assert.Equal(t, []string{".*"}, matchPaths(t, doc, `\*`))
+}
+
+func TestWalkMatchPrune(t *testing.T) {
+	data, err := amorph.NewAmorphFromFile("test.json")
+	assert.Nil(t, err)
+	// the callback's error stops the walk
+	stop := errors.New("stop")
+	visits := 0
+	err = amorph.WalkMatch(data, "**.webaddresses[*]", func(iter amorph.WalkIter) error {
+		visits++
+		return stop
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, visits)
+
+	for _, pattern := range []string{"a[", "a[x]", "a[-1]", "a[0]b", `a\`} {
+		err = amorph.WalkMatch(data, pattern, func(iter amorph.WalkIter) error { return nil })
+		assert.Equal(t, amorph.ErrPatternSyntax, err, pattern)
+	}
+}